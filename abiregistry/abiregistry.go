@@ -0,0 +1,293 @@
+// Package abiregistry holds contract abis keyed by address. Abis are loaded
+// from raw abi json or from hardhat, hardhat-deploy, foundry and truffle
+// artifacts, and are used both in the configs sent to the api and to decode
+// the input of transactions locally.
+package abiregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// ManifestFile is the name of the file binding contract names to addresses
+// in a directory loaded by LoadDir, it holds a json object mapping addresses
+// to the names of the contracts deployed at them
+const ManifestFile = "addresses.json"
+
+var (
+	// ErrUnknownContract is returned when decoding a call to an address without an abi
+	ErrUnknownContract = errors.New("unknown contract")
+	// ErrUnknownMethod is returned when decoding a call to a method missing from the abi of its contract
+	ErrUnknownMethod = errors.New("unknown method")
+	// errNoABI is returned for json objects which are not artifacts
+	errNoABI = errors.New("no abi")
+)
+
+// Contract is an abi along with the name and address of the contract implementing it
+type Contract struct {
+	Name string
+	// Address is zero for contracts only known by name
+	Address common.Address
+	ABI     abi.ABI
+	// raw is the abi json, decoded for use in client.Config
+	raw interface{}
+}
+
+// JSON returns the abi as decoded json, the form taken by client.Config.ABI
+func (c *Contract) JSON() interface{} {
+	return c.raw
+}
+
+// Registry holds contract abis keyed by address and by name
+type Registry struct {
+	mx        sync.RWMutex
+	byAddress map[common.Address]*Contract
+	byName    map[string]*Contract
+}
+
+// New returns an empty registry
+func New() *Registry {
+	return &Registry{
+		byAddress: make(map[common.Address]*Contract),
+		byName:    make(map[string]*Contract),
+	}
+}
+
+// artifact is the subset of the artifacts of common toolchains used by the registry
+type artifact struct {
+	ContractName string          `json:"contractName"`
+	Address      string          `json:"address"`
+	ABI          json.RawMessage `json:"abi"`
+}
+
+// Parse returns the contract described by data, either raw abi json or an
+// artifact. The name and address are taken from the artifact when present.
+func Parse(data []byte) (*Contract, error) {
+	var art artifact
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &art); err != nil {
+			return nil, errors.Wrap(err, "decoding artifact")
+		}
+		if len(art.ABI) == 0 {
+			return nil, errNoABI
+		}
+		data = art.ABI
+	}
+	parsed, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid abi")
+	}
+	c := &Contract{Name: art.ContractName, ABI: parsed}
+	if err := json.Unmarshal(data, &c.raw); err != nil {
+		return nil, errors.Wrap(err, "invalid abi")
+	}
+	if art.Address != "" {
+		if !common.IsHexAddress(art.Address) {
+			return nil, errors.Errorf("invalid artifact address:%v", art.Address)
+		}
+		c.Address = common.HexToAddress(art.Address)
+	}
+	return c, nil
+}
+
+// ReadFile parses the abi or artifact at path. Contracts are named after the
+// file when the artifact does not name them, and files named after an
+// address, such as 0x...json, hold the abi of the contract at that address.
+func ReadFile(path string) (*Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading abi path:%v", path)
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".json"), ".abi")
+	if common.IsHexAddress(name) && c.Address == (common.Address{}) {
+		c.Address = common.HexToAddress(name)
+	} else if c.Name == "" {
+		c.Name = name
+	}
+	return c, nil
+}
+
+// Add registers c under its address and name. Contracts replace those
+// registered before under the same address or name.
+func (r *Registry) Add(c *Contract) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if c.Address != (common.Address{}) {
+		r.byAddress[c.Address] = c
+	}
+	if c.Name != "" {
+		r.byName[c.Name] = c
+	}
+}
+
+// LoadFile registers the abi or artifact at path, see ReadFile
+func (r *Registry) LoadFile(path string) error {
+	c, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	r.Add(c)
+	return nil
+}
+
+// LoadDir registers every abi and artifact under dir. Json files holding
+// neither, such as build info and debug files, are skipped. Contracts known
+// only by name are bound to addresses by a ManifestFile in dir.
+func (r *Registry) LoadDir(dir string) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" || path == filepath.Join(dir, ManifestFile) {
+			return nil
+		}
+		err = r.LoadFile(path)
+		if errors.Is(err, errNoABI) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return errors.Wrapf(err, "decoding manifest path:%v", ManifestFile)
+	}
+	for address, name := range manifest {
+		if err := r.Bind(address, name); err != nil {
+			return errors.Wrapf(err, "manifest path:%v", ManifestFile)
+		}
+	}
+	return nil
+}
+
+// Bind registers the abi of the contract named name for address
+func (r *Registry) Bind(address, name string) error {
+	if !common.IsHexAddress(address) {
+		return errors.Errorf("invalid address:%v", address)
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	c, ok := r.byName[name]
+	if !ok {
+		return errors.Errorf("unknown contract name:%v", name)
+	}
+	bound := *c
+	bound.Address = common.HexToAddress(address)
+	r.byAddress[bound.Address] = &bound
+	return nil
+}
+
+// Lookup returns the contract at address
+func (r *Registry) Lookup(address string) (*Contract, bool) {
+	if !common.IsHexAddress(address) {
+		return nil, false
+	}
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	c, ok := r.byAddress[common.HexToAddress(address)]
+	return c, ok
+}
+
+// Contracts returns the contracts with an address ordered by address
+func (r *Registry) Contracts() []*Contract {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	out := make([]*Contract, 0, len(r.byAddress))
+	for _, c := range r.byAddress {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return bytes.Compare(out[i].Address[:], out[j].Address[:]) < 0 })
+	return out
+}
+
+// Config returns a new config for scope with the abi registered for it
+func (r *Registry) Config(scope string, watchAddress bool) client.Config {
+	var abis interface{}
+	if c, ok := r.Lookup(scope); ok {
+		abis = c.JSON()
+	}
+	return client.NewConfig(scope, watchAddress, abis)
+}
+
+// FillABIs sets the abi of the configs scoped to a registered address which have none
+func (r *Registry) FillABIs(configs []client.Config) {
+	for i, cfg := range configs {
+		if cfg.ABI != nil {
+			continue
+		}
+		if c, ok := r.Lookup(cfg.Scope); ok {
+			configs[i].ABI = c.JSON()
+		}
+	}
+}
+
+// DecodeCall decodes the input of tx with the abi of the contract it is sent to
+func (r *Registry) DecodeCall(tx *client.TransactionPayload) (*client.ContractCall, error) {
+	c, ok := r.Lookup(tx.To)
+	if !ok {
+		return nil, ErrUnknownContract
+	}
+	input, err := hexutil.Decode(tx.Input)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid input")
+	}
+	if len(input) < 4 {
+		return nil, ErrUnknownMethod
+	}
+	method, err := c.ABI.MethodById(input[:4])
+	if err != nil {
+		return nil, ErrUnknownMethod
+	}
+	params, err := client.UnpackParams(method.Inputs, input[4:])
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding input method:%v", method.RawName)
+	}
+	return &client.ContractCall{
+		ContractAddress: tx.To,
+		ContractName:    c.Name,
+		MethodName:      method.RawName,
+		Params:          params,
+	}, nil
+}
+
+// Handler sets the contract call of transactions the api did not decode
+// before passing them to next. Transactions which cannot be decoded are
+// passed on unchanged.
+func (r *Registry) Handler(next client.Handler) client.Handler {
+	return func(ctx context.Context, msg *client.EthTxPayload) error {
+		tx := &msg.Event.Transaction
+		if tx.ContractCall == nil {
+			if call, err := r.DecodeCall(tx); err == nil {
+				tx.ContractCall = call
+			}
+		}
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,109 @@
+package abiregistry
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+const (
+	usdt  = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	token = "0x2222222222222222222222222222222222222222"
+	vault = "0x3333333333333333333333333333333333333333"
+	// transfer(0x1111111111111111111111111111111111111111, 1000)
+	transferInput = "0xa9059cbb000000000000000000000000111111111111111111111111111111111111111100000000000000000000000000000000000000000000000000000000000003e8"
+)
+
+func load(t *testing.T) *Registry {
+	r := New()
+	require.NoError(t, r.LoadDir(filepath.Join("testdata", "abis")))
+	return r
+}
+
+func TestLoadDir(t *testing.T) {
+	r := load(t)
+	contracts := r.Contracts()
+	require.Len(t, contracts, 3)
+	// bound by the manifest
+	require.Equal(t, common.HexToAddress(token), contracts[0].Address)
+	require.Equal(t, "Token", contracts[0].Name)
+	// deployment artifact holding its address
+	require.Equal(t, common.HexToAddress(vault), contracts[1].Address)
+	require.Equal(t, "Vault", contracts[1].Name)
+	require.Contains(t, contracts[1].ABI.Methods, "deposit")
+	// raw abi named after its address
+	require.Equal(t, common.HexToAddress(usdt), contracts[2].Address)
+
+	_, ok := r.Lookup("0x4444444444444444444444444444444444444444")
+	require.False(t, ok)
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse([]byte(`[{"type":"function","name":"f","inputs":[{"name":"a","type":"notatype"}]}]`))
+	require.ErrorContains(t, err, "invalid abi")
+	_, err = Parse([]byte(`{"contractName": "NoABI"}`))
+	require.ErrorIs(t, err, errNoABI)
+	_, err = Parse([]byte(`{"address": "0x01", "abi": []}`))
+	require.ErrorContains(t, err, "invalid artifact address")
+
+	r := New()
+	require.ErrorContains(t, r.Bind(token, "Missing"), "unknown contract name:Missing")
+}
+
+func TestConfig(t *testing.T) {
+	r := load(t)
+	cfg := r.Config(usdt, true)
+	require.Equal(t, usdt, cfg.Scope)
+	require.Len(t, cfg.ABI, 1)
+	require.Nil(t, r.Config("global", false).ABI)
+
+	configs := []client.Config{{Scope: token}, {Scope: "global"}, {Scope: vault, ABI: []interface{}{}}}
+	r.FillABIs(configs)
+	require.Len(t, configs[0].ABI, 1)
+	require.Nil(t, configs[1].ABI)
+	require.Empty(t, configs[2].ABI)
+}
+
+func TestDecodeCall(t *testing.T) {
+	r := load(t)
+	call, err := r.DecodeCall(&client.TransactionPayload{To: token, Input: transferInput})
+	require.NoError(t, err)
+	require.Equal(t, "transfer", call.MethodName)
+	require.Equal(t, "Token", call.ContractName)
+	to, ok := call.Params.Address("to")
+	require.True(t, ok)
+	require.Equal(t, common.HexToAddress("0x1111111111111111111111111111111111111111"), to)
+	amount, ok := call.Params.BigInt("amount")
+	require.True(t, ok)
+	require.Equal(t, int64(1000), amount.Int64())
+
+	_, err = r.DecodeCall(&client.TransactionPayload{To: "0x4444444444444444444444444444444444444444", Input: transferInput})
+	require.ErrorIs(t, err, ErrUnknownContract)
+	_, err = r.DecodeCall(&client.TransactionPayload{To: vault, Input: transferInput})
+	require.ErrorIs(t, err, ErrUnknownMethod)
+	_, err = r.DecodeCall(&client.TransactionPayload{To: token, Input: transferInput[:20]})
+	require.ErrorContains(t, err, "decoding input method:transfer")
+}
+
+func TestHandler(t *testing.T) {
+	r := load(t)
+	var got *client.ContractCall
+	handler := r.Handler(func(ctx context.Context, msg *client.EthTxPayload) error {
+		got = msg.Event.Transaction.ContractCall
+		return nil
+	})
+	msg := &client.EthTxPayload{}
+	msg.Event.Transaction = client.TransactionPayload{To: token, Input: transferInput}
+	require.NoError(t, handler(context.Background(), msg))
+	require.Equal(t, "transfer", got.MethodName)
+
+	// calls decoded by the api are kept
+	decoded := &client.ContractCall{MethodName: "fromApi"}
+	msg.Event.Transaction.ContractCall = decoded
+	require.NoError(t, handler(context.Background(), msg))
+	require.Same(t, decoded, got)
+}
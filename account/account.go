@@ -0,0 +1,169 @@
+// Package account is a client for the blocknative rest api managing the
+// configurations and watched addresses stored for an api key. Unlike those
+// sent with Client.EventSub they persist beyond a websocket connection.
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+	"github.com/tiennampham23/go-blocknative/internal/rest"
+)
+
+const (
+	// DefaultBaseURL is the url of the blocknative rest api
+	DefaultBaseURL = rest.DefaultBaseURL
+	// DefaultBackoff is the delay before the first retry of a request
+	DefaultBackoff = rest.DefaultBackoff
+)
+
+// Opts provides configuration over the account client
+type Opts struct {
+	// APIKey the configurations and addresses are stored for, BLOCKNATIVE_DAPP_ID is used if empty
+	APIKey string
+	// BaseURL overrides DefaultBaseURL
+	BaseURL string
+	// MaxRetries is the number of times a failed idempotent request is retried
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubling on every attempt
+	Backoff    time.Duration
+	HTTPClient *http.Client
+}
+
+// Client manages the configurations and watched addresses of an api key
+type Client struct {
+	rest *rest.Client
+}
+
+// APIError is returned for requests the api responded to with an error status
+type APIError = rest.APIError
+
+// NewClient returns a new account client
+func NewClient(opts Opts) *Client {
+	return &Client{rest: rest.NewClient(rest.Opts{
+		API:        "account",
+		APIKey:     opts.APIKey,
+		BaseURL:    opts.BaseURL,
+		MaxRetries: opts.MaxRetries,
+		Backoff:    opts.Backoff,
+		HTTPClient: opts.HTTPClient,
+	})}
+}
+
+// addressRequest is the body of address requests
+type addressRequest struct {
+	APIKey     string   `json:"apiKey"`
+	Address    string   `json:"address"`
+	Blockchain string   `json:"blockchain"`
+	Networks   []string `json:"networks"`
+}
+
+// configRequest is the body of config requests
+type configRequest struct {
+	APIKey     string            `json:"apiKey"`
+	Blockchain client.Blockchain `json:"blockchain"`
+	Config     *client.Config    `json:"config,omitempty"`
+	Scope      string            `json:"scope,omitempty"`
+}
+
+// listResponse is the body of list responses
+type listResponse struct {
+	Items json.RawMessage `json:"items"`
+	Count int             `json:"count"`
+}
+
+// Addresses returns the addresses watched on the network
+func (c *Client) Addresses(ctx context.Context, chain client.Blockchain) ([]string, error) {
+	var items []struct {
+		Address string `json:"address"`
+	}
+	if err := c.list(ctx, "/address", chain, &items); err != nil {
+		return nil, err
+	}
+	addresses := make([]string, len(items))
+	for i, item := range items {
+		addresses[i] = item.Address
+	}
+	return addresses, nil
+}
+
+// WatchAddress stores address as watched on the network
+func (c *Client) WatchAddress(ctx context.Context, chain client.Blockchain, address string) error {
+	return c.do(ctx, http.MethodPost, "/address", c.addressRequest(chain, address), nil)
+}
+
+// UnwatchAddress removes address from the addresses watched on the network
+func (c *Client) UnwatchAddress(ctx context.Context, chain client.Blockchain, address string) error {
+	return c.do(ctx, http.MethodDelete, "/address", c.addressRequest(chain, address), nil)
+}
+
+func (c *Client) addressRequest(chain client.Blockchain, address string) addressRequest {
+	return addressRequest{APIKey: c.rest.APIKey(), Address: address, Blockchain: chain.System, Networks: []string{chain.Network}}
+}
+
+// Configs returns the configurations stored for the network
+func (c *Client) Configs(ctx context.Context, chain client.Blockchain) ([]client.Config, error) {
+	var configs []client.Config
+	if err := c.list(ctx, "/configs", chain, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// CreateConfig stores a configuration for a scope which has none
+func (c *Client) CreateConfig(ctx context.Context, chain client.Blockchain, cfg client.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, "/configs", configRequest{APIKey: c.rest.APIKey(), Blockchain: chain, Config: &cfg}, nil)
+}
+
+// UpdateConfig replaces the configuration stored for the scope of cfg
+func (c *Client) UpdateConfig(ctx context.Context, chain client.Blockchain, cfg client.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPut, "/configs", configRequest{APIKey: c.rest.APIKey(), Blockchain: chain, Config: &cfg}, nil)
+}
+
+// DeleteConfig removes the configuration stored for scope
+func (c *Client) DeleteConfig(ctx context.Context, chain client.Blockchain, scope string) error {
+	return c.do(ctx, http.MethodDelete, "/configs", configRequest{APIKey: c.rest.APIKey(), Blockchain: chain, Scope: scope}, nil)
+}
+
+// list decodes the items listed at /<resource>/<api key>/<system>/<network>
+func (c *Client) list(ctx context.Context, resource string, chain client.Blockchain, items interface{}) error {
+	path := resource + "/" + url.PathEscape(c.rest.APIKey()) + "/" + url.PathEscape(chain.System) + "/" + url.PathEscape(chain.Network)
+	var resp listResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		// path contains the api key so errors name the resource instead
+		return errors.Wrapf(err, "listing path:%v", resource)
+	}
+	if len(resp.Items) == 0 {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(resp.Items, items), "decoding items path:%v", resource)
+}
+
+// do sends in as the json body of a request, decoding the response into
+// out if it is not nil. Requests other than POST are retried with backoff
+// on network errors, server errors and rate limiting.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return errors.Wrap(err, "marshal request")
+		}
+	}
+	resp, err := c.rest.Do(ctx, method, path, body, method != http.MethodPost)
+	if err != nil || out == nil {
+		return err
+	}
+	return errors.Wrap(json.Unmarshal(resp, out), "decoding response")
+}
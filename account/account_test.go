@@ -0,0 +1,178 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+const testAddress = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+var mainnet = client.Blockchain{System: "ethereum", Network: "main"}
+
+// fakeAPI stores the addresses and configs of the key "key"
+type fakeAPI struct {
+	t         *testing.T
+	mx        sync.Mutex
+	addresses map[string]bool
+	configs   map[string]client.Config
+}
+
+func newFakeAPI(t *testing.T) *Client {
+	api := &fakeAPI{t: t, addresses: make(map[string]bool), configs: make(map[string]client.Config)}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return NewClient(Opts{APIKey: "key", BaseURL: srv.URL})
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	require.Equal(f.t, "key", r.Header.Get("Authorization"))
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/address/key/ethereum/main":
+		var items []addressRequest
+		for address := range f.addresses {
+			items = append(items, addressRequest{Address: address})
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].Address < items[j].Address })
+		f.list(w, items)
+	case r.URL.Path == "/address":
+		var req addressRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(f.t, addressRequest{APIKey: "key", Address: req.Address, Blockchain: "ethereum", Networks: []string{"main"}}, req)
+		if r.Method == http.MethodPost {
+			f.addresses[req.Address] = true
+		} else {
+			delete(f.addresses, req.Address)
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/configs/key/ethereum/main":
+		var items []client.Config
+		for _, cfg := range f.configs {
+			items = append(items, cfg)
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].Scope < items[j].Scope })
+		f.list(w, items)
+	case r.URL.Path == "/configs":
+		var req configRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(f.t, mainnet, req.Blockchain)
+		_, exists := f.configs[req.scope()]
+		switch r.Method {
+		case http.MethodPost:
+			if exists {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"msg": "config exists"}`))
+				return
+			}
+			f.configs[req.Config.Scope] = *req.Config
+		case http.MethodPut:
+			f.configs[req.Config.Scope] = *req.Config
+		case http.MethodDelete:
+			delete(f.configs, req.Scope)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (req configRequest) scope() string {
+	if req.Config != nil {
+		return req.Config.Scope
+	}
+	return req.Scope
+}
+
+func (f *fakeAPI) list(w http.ResponseWriter, items interface{}) {
+	data, err := json.Marshal(items)
+	require.NoError(f.t, err)
+	require.NoError(f.t, json.NewEncoder(w).Encode(listResponse{Items: data}))
+}
+
+func TestAddresses(t *testing.T) {
+	c := newFakeAPI(t)
+	ctx := context.Background()
+	require.NoError(t, c.WatchAddress(ctx, mainnet, testAddress))
+	require.NoError(t, c.WatchAddress(ctx, mainnet, "0x01"))
+	addresses, err := c.Addresses(ctx, mainnet)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0x01", testAddress}, addresses)
+
+	require.NoError(t, c.UnwatchAddress(ctx, mainnet, "0x01"))
+	addresses, err = c.Addresses(ctx, mainnet)
+	require.NoError(t, err)
+	require.Equal(t, []string{testAddress}, addresses)
+}
+
+func TestConfigs(t *testing.T) {
+	c := newFakeAPI(t)
+	ctx := context.Background()
+	configs, err := c.Configs(ctx, mainnet)
+	require.NoError(t, err)
+	require.Empty(t, configs)
+
+	cfg := client.NewConfig(testAddress, true, nil)
+	cfg.Filters = []map[string]string{{"status": "pending"}}
+	require.NoError(t, c.CreateConfig(ctx, mainnet, cfg))
+	err = c.CreateConfig(ctx, mainnet, cfg)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	require.Equal(t, "config exists", apiErr.Message)
+
+	cfg.Filters = []map[string]string{{"status": "confirmed"}}
+	require.NoError(t, c.UpdateConfig(ctx, mainnet, cfg))
+	require.NoError(t, c.CreateConfig(ctx, mainnet, client.NewConfig("global", false, nil)))
+	configs, err = c.Configs(ctx, mainnet)
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	require.Equal(t, cfg, configs[0])
+
+	require.NoError(t, c.DeleteConfig(ctx, mainnet, testAddress))
+	configs, err = c.Configs(ctx, mainnet)
+	require.NoError(t, err)
+	require.Equal(t, []client.Config{client.NewConfig("global", false, nil)}, configs)
+
+	// invalid configs are rejected locally
+	require.Error(t, c.CreateConfig(ctx, mainnet, client.NewConfig("nope", false, nil)))
+}
+
+func TestRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	c := NewClient(Opts{APIKey: "key", BaseURL: srv.URL, MaxRetries: 2, Backoff: time.Millisecond})
+
+	_, err := c.Addresses(context.Background(), mainnet)
+	require.Error(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// creating is not idempotent so it is not retried
+	require.Error(t, c.WatchAddress(context.Background(), mainnet, testAddress))
+	require.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestListErrorHidesKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer srv.Close()
+	c := NewClient(Opts{APIKey: "secret-key", BaseURL: srv.URL})
+
+	_, err := c.Addresses(context.Background(), mainnet)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "listing path:/address")
+	require.NotContains(t, err.Error(), "secret-key")
+}
@@ -0,0 +1,173 @@
+// Package admin provides an http api for managing the watches of a
+// running client without restarting it
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// Controller manages the watches of a connection, it is implemented by client.Client
+type Controller interface {
+	Status() client.Status
+	Subscriptions() client.Subscriptions
+	WatchAddress(ctx context.Context, address string) error
+	UnwatchAddress(ctx context.Context, address string) error
+	WatchTx(ctx context.Context, txHash string) error
+	UnwatchTx(ctx context.Context, txHash string) error
+	SetConfig(ctx context.Context, cfg client.Config) error
+	RemoveConfig(ctx context.Context, scope string) error
+}
+
+// Server serves the admin api. Changes are made through the controller, a
+// client.Client persists them when it is created with a History.
+type Server struct {
+	ctl   Controller
+	token string
+	mx    sync.Mutex
+}
+
+// New returns a new admin server. When token is not empty requests must
+// carry it in an "Authorization: Bearer <token>" header.
+func New(ctl Controller, token string) *Server {
+	return &Server{ctl: ctl, token: token}
+}
+
+// Handler returns the http handler of the admin api:
+//
+//	GET    /status
+//	GET    /subscriptions
+//	GET    /addresses         POST /addresses {"address": "0x..."}  DELETE /addresses/{address}
+//	GET    /txs               POST /txs {"hash": "0x..."}           DELETE /txs/{hash}
+//	GET    /configs           POST /configs {"scope": "0x...", ...} DELETE /configs/{scope}
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.get(func() interface{} { return s.ctl.Status() }))
+	mux.HandleFunc("/subscriptions", s.get(func() interface{} { return s.ctl.Subscriptions() }))
+	s.resource(mux, "/addresses",
+		func() interface{} { return s.ctl.Subscriptions().Addresses },
+		func(ctx context.Context, body []byte) error {
+			var req struct {
+				Address string `json:"address"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil || req.Address == "" {
+				return errBadRequest
+			}
+			return s.ctl.WatchAddress(ctx, req.Address)
+		},
+		s.ctl.UnwatchAddress,
+	)
+	s.resource(mux, "/txs",
+		func() interface{} { return s.ctl.Subscriptions().TxHashes },
+		func(ctx context.Context, body []byte) error {
+			var req struct {
+				Hash string `json:"hash"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil || req.Hash == "" {
+				return errBadRequest
+			}
+			return s.ctl.WatchTx(ctx, req.Hash)
+		},
+		s.ctl.UnwatchTx,
+	)
+	s.resource(mux, "/configs",
+		func() interface{} { return s.ctl.Subscriptions().Configs },
+		func(ctx context.Context, body []byte) error {
+			var cfg client.Config
+			if err := json.Unmarshal(body, &cfg); err != nil || cfg.Scope == "" {
+				return errBadRequest
+			}
+			return s.ctl.SetConfig(ctx, cfg)
+		},
+		s.ctl.RemoveConfig,
+	)
+	return s.authorize(mux)
+}
+
+// authorize rejects requests without the token when one is set
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var errBadRequest = errors.New("invalid request body")
+
+func (s *Server) get(fn func() interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, fn())
+	}
+}
+
+// resource registers list and add handlers on path and a remove handler on path/{key}
+func (s *Server) resource(
+	mux *http.ServeMux,
+	path string,
+	list func() interface{},
+	add func(ctx context.Context, body []byte) error,
+	remove func(ctx context.Context, key string) error,
+) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, list())
+		case http.MethodPost:
+			var body json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, errBadRequest.Error(), http.StatusBadRequest)
+				return
+			}
+			s.apply(w, func() error { return add(r.Context(), body) }, http.StatusCreated, list)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc(path+"/", func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, path+"/")
+		if r.Method != http.MethodDelete || key == "" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.apply(w, func() error { return remove(r.Context(), key) }, http.StatusOK, list)
+	})
+}
+
+// apply runs a change and responds with the updated list
+func (s *Server) apply(w http.ResponseWriter, change func() error, status int, list func() interface{}) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if err := change(); err != nil {
+		code := http.StatusBadGateway
+		if err == errBadRequest {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	writeJSON(w, status, list())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
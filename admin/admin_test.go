@@ -0,0 +1,141 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+type fakeController struct {
+	addresses map[string]bool
+	txHashes  map[string]bool
+	configs   map[string]client.Config
+}
+
+func newFakeController() *fakeController {
+	return &fakeController{
+		addresses: map[string]bool{},
+		txHashes:  map[string]bool{},
+		configs:   map[string]client.Config{},
+	}
+}
+
+func keys(m map[string]bool) []string {
+	out := []string{}
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (f *fakeController) Status() client.Status {
+	return client.Status{Connected: true, ConnectionID: "conn", ServerVersion: "1.0.0"}
+}
+
+func (f *fakeController) Subscriptions() client.Subscriptions {
+	subs := client.Subscriptions{Addresses: keys(f.addresses), TxHashes: keys(f.txHashes), Configs: []client.Config{}}
+	for _, cfg := range f.configs {
+		subs.Configs = append(subs.Configs, cfg)
+	}
+	return subs
+}
+
+func (f *fakeController) WatchAddress(_ context.Context, address string) error {
+	f.addresses[address] = true
+	return nil
+}
+
+func (f *fakeController) UnwatchAddress(_ context.Context, address string) error {
+	delete(f.addresses, address)
+	return nil
+}
+
+func (f *fakeController) WatchTx(_ context.Context, txHash string) error {
+	f.txHashes[txHash] = true
+	return nil
+}
+
+func (f *fakeController) UnwatchTx(_ context.Context, txHash string) error {
+	delete(f.txHashes, txHash)
+	return nil
+}
+
+func (f *fakeController) SetConfig(_ context.Context, cfg client.Config) error {
+	if cfg.Scope == "rejected" {
+		return errors.New("failed to create subscription reason:invalid config")
+	}
+	f.configs[cfg.Scope] = cfg
+	return nil
+}
+
+func (f *fakeController) RemoveConfig(_ context.Context, scope string) error {
+	delete(f.configs, scope)
+	return nil
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path, body string, out interface{}) int {
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func TestServer(t *testing.T) {
+	ctl := newFakeController()
+	srv := httptest.NewServer(New(ctl, "").Handler())
+	defer srv.Close()
+
+	var status client.Status
+	require.Equal(t, http.StatusOK, do(t, srv, http.MethodGet, "/status", "", &status))
+	require.Equal(t, "conn", status.ConnectionID)
+	require.Equal(t, "1.0.0", status.ServerVersion)
+
+	var addresses []string
+	require.Equal(t, http.StatusCreated, do(t, srv, http.MethodPost, "/addresses", `{"address":"0xaa"}`, &addresses))
+	require.Equal(t, http.StatusCreated, do(t, srv, http.MethodPost, "/addresses", `{"address":"0xbb"}`, &addresses))
+	require.Equal(t, []string{"0xaa", "0xbb"}, addresses)
+	require.Equal(t, http.StatusOK, do(t, srv, http.MethodDelete, "/addresses/0xaa", "", &addresses))
+	require.Equal(t, []string{"0xbb"}, addresses)
+	require.Equal(t, http.StatusBadRequest, do(t, srv, http.MethodPost, "/addresses", `{}`, nil))
+
+	var hashes []string
+	require.Equal(t, http.StatusCreated, do(t, srv, http.MethodPost, "/txs", `{"hash":"0x01"}`, &hashes))
+	require.Equal(t, []string{"0x01"}, hashes)
+
+	var configs []client.Config
+	require.Equal(t, http.StatusCreated, do(t, srv, http.MethodPost, "/configs", `{"scope":"global","filters":[{"status":"pending"}]}`, &configs))
+	require.Len(t, configs, 1)
+	require.Equal(t, "pending", configs[0].Filters[0]["status"])
+
+	// configs rejected by the api are reported as upstream failures
+	require.Equal(t, http.StatusBadGateway, do(t, srv, http.MethodPost, "/configs", `{"scope":"rejected"}`, nil))
+	require.Len(t, ctl.Subscriptions().Configs, 1)
+}
+
+func TestServerToken(t *testing.T) {
+	srv := httptest.NewServer(New(newFakeController(), "secret").Handler())
+	defer srv.Close()
+	require.Equal(t, http.StatusUnauthorized, do(t, srv, http.MethodPost, "/addresses", `{"address":"0xaa"}`, nil))
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/status", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,193 @@
+// Package calltree rebuilds the call tree of a transaction from the flat
+// list of its internal transactions, and summarizes the ether it moves
+package calltree
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// Call types
+const (
+	TypeCall         = "CALL"
+	TypeCreate       = "CREATE"
+	TypeDelegateCall = "DELEGATECALL"
+	TypeCallCode     = "CALLCODE"
+	TypeStaticCall   = "STATICCALL"
+)
+
+// Node is a call of the tree. The root is the call made by the transaction itself.
+type Node struct {
+	Call     client.InternalTransaction
+	Parent   *Node
+	Children []*Node
+	// Depth is 0 for the root
+	Depth int
+	// Index is the index of the call in TransactionPayload.InternalTransactions, -1 for the root
+	Index int
+	// context is the address whose storage and balance the call runs against
+	context string
+}
+
+// Build returns the call tree of tx. Calls are nested using their trace
+// addresses, or else their depths, when every internal transaction has them.
+// Otherwise the internal transactions are taken to be in execution order and
+// every call is nested under the latest call running against the address it
+// is made from, which is ambiguous for contracts calling back into their callers.
+func Build(tx *client.TransactionPayload) *Node {
+	root := &Node{
+		Call: client.InternalTransaction{
+			Type:        TypeCall,
+			From:        tx.From,
+			To:          tx.To,
+			Input:       tx.Input,
+			Gas:         int(tx.Gas),
+			GasUsed:     int(tx.GasUsed),
+			Value:       tx.Value,
+			ErrorReason: tx.SimError,
+		},
+		Index:   -1,
+		context: strings.ToLower(tx.To),
+	}
+	if tx.To == "" {
+		root.Call.Type = TypeCreate
+	}
+	if tx.ContractCall != nil {
+		root.Call.ContractCall = *tx.ContractCall
+	}
+	calls := tx.InternalTransactions
+	switch {
+	case all(calls, func(itx client.InternalTransaction) bool { return len(itx.TraceAddress) > 0 }):
+		buildByTraceAddress(root, calls)
+	case all(calls, func(itx client.InternalTransaction) bool { return itx.Depth > 0 }):
+		buildByDepth(root, calls)
+	default:
+		buildByAddress(root, calls)
+	}
+	return root
+}
+
+func all(calls []client.InternalTransaction, ok func(client.InternalTransaction) bool) bool {
+	for _, itx := range calls {
+		if !ok(itx) {
+			return false
+		}
+	}
+	return len(calls) > 0
+}
+
+func buildByTraceAddress(root *Node, calls []client.InternalTransaction) {
+	nodes := map[string]*Node{"": root}
+	for i, itx := range calls {
+		parent, ok := nodes[traceKey(itx.TraceAddress[:len(itx.TraceAddress)-1])]
+		if !ok {
+			parent = root
+		}
+		nodes[traceKey(itx.TraceAddress)] = parent.add(itx, i)
+	}
+}
+
+func traceKey(address []int) string {
+	var b strings.Builder
+	for _, i := range address {
+		b.WriteByte('/')
+		b.WriteString(strconv.Itoa(i))
+	}
+	return b.String()
+}
+
+func buildByDepth(root *Node, calls []client.InternalTransaction) {
+	stack := []*Node{root}
+	for i, itx := range calls {
+		if itx.Depth < len(stack) {
+			stack = stack[:itx.Depth]
+		}
+		stack = append(stack, stack[len(stack)-1].add(itx, i))
+	}
+}
+
+func buildByAddress(root *Node, calls []client.InternalTransaction) {
+	stack := []*Node{root}
+	for i, itx := range calls {
+		from := strings.ToLower(itx.From)
+		// unwind to the latest call running against the sender, or the root when there is none
+		j := len(stack) - 1
+		for j > 0 && stack[j].context != from {
+			j--
+		}
+		stack = stack[:j+1]
+		stack = append(stack, stack[j].add(itx, i))
+	}
+}
+
+// add appends a child node for itx
+func (n *Node) add(itx client.InternalTransaction, index int) *Node {
+	child := &Node{Call: itx, Parent: n, Depth: n.Depth + 1, Index: index, context: strings.ToLower(itx.To)}
+	// delegated calls run against the storage and balance of their caller
+	if itx.Type == TypeDelegateCall || itx.Type == TypeCallCode {
+		child.context = n.context
+	}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// Walk calls fn for n and its descendants in execution order, skipping the
+// descendants of nodes for which fn returns false
+func (n *Node) Walk(fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range n.Children {
+		child.Walk(fn)
+	}
+}
+
+// Nodes returns n and its descendants in execution order
+func (n *Node) Nodes() []*Node {
+	return n.Find(func(*Node) bool { return true })
+}
+
+// Find returns the nodes under n, including n, matching match in execution order
+func (n *Node) Find(match func(*Node) bool) []*Node {
+	var out []*Node
+	n.Walk(func(node *Node) bool {
+		if match(node) {
+			out = append(out, node)
+		}
+		return true
+	})
+	return out
+}
+
+// Path returns the calls leading from the root to n
+func (n *Node) Path() []*Node {
+	var path []*Node
+	for node := n; node != nil; node = node.Parent {
+		path = append([]*Node{node}, path...)
+	}
+	return path
+}
+
+// Reverted reports whether the effects of the call were undone because it
+// or one of its callers failed
+func (n *Node) Reverted() bool {
+	for node := n; node != nil; node = node.Parent {
+		if node.Call.ErrorReason != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// MethodName returns the name of the method called, or its selector when the call was not decoded
+func (n *Node) MethodName() string {
+	if n.Call.ContractCall.MethodName != "" {
+		return n.Call.ContractCall.MethodName
+	}
+	if len(n.Call.Input) >= 10 {
+		return n.Call.Input[:10]
+	}
+	return ""
+}
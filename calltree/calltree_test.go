@@ -0,0 +1,126 @@
+package calltree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+const (
+	searcher = "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	bot      = "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	weth     = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+	pair     = "0xcccccccccccccccccccccccccccccccccccccccc"
+	usdc     = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	impl     = "0xdddddddddddddddddddddddddddddddddddddddd"
+	builder  = "0x1111111111111111111111111111111111111111"
+	victim   = "0x2222222222222222222222222222222222222222"
+	refund   = "0x3333333333333333333333333333333333333333"
+)
+
+// bundle is a pending transaction of a searcher contract: it wraps ether,
+// swaps through a pair, pays the block builder and makes a call that reverts
+func bundle() *client.TransactionPayload {
+	return &client.TransactionPayload{
+		From:         searcher,
+		To:           bot,
+		Value:        "0",
+		Input:        "0x12345678",
+		ContractCall: &client.ContractCall{MethodName: "execute"},
+		InternalTransactions: []client.InternalTransaction{
+			{Type: TypeCall, From: bot, To: weth, Value: "1000000000000000000", Input: "0xd0e30db0"},
+			{Type: TypeCall, From: bot, To: pair, Value: "0", Input: "0x022c0d9f"},
+			{Type: TypeCall, From: pair, To: usdc, Value: "0", ContractCall: client.ContractCall{MethodName: "transfer"}},
+			{Type: TypeDelegateCall, From: usdc, To: impl, Value: "0", ContractCall: client.ContractCall{MethodName: "transfer"}},
+			{Type: TypeStaticCall, From: usdc, To: pair, Value: "0", Input: "0x70a08231"},
+			{Type: TypeCall, From: bot, To: builder, Value: "50000000000000000"},
+			{Type: TypeCall, From: bot, To: victim, Value: "100000000000000000", ErrorReason: "out of gas"},
+			{Type: TypeCall, From: victim, To: refund, Value: "100000000000000000"},
+		},
+	}
+}
+
+// shape returns the index of the parent of every internal transaction
+func shape(root *Node) []int {
+	var parents []int
+	root.Walk(func(n *Node) bool {
+		if n.Parent != nil {
+			parents = append(parents, n.Parent.Index)
+		}
+		return true
+	})
+	return parents
+}
+
+func TestBuild(t *testing.T) {
+	root := Build(bundle())
+	require.Equal(t, -1, root.Index)
+	require.Equal(t, "execute", root.MethodName())
+	// the delegated call runs against usdc, so the static call from usdc is nested under it
+	require.Equal(t, []int{-1, -1, 1, 2, 3, -1, -1, 6}, shape(root))
+	require.Len(t, root.Nodes(), 9)
+
+	staticCall := root.Find(func(n *Node) bool { return n.Call.Type == TypeStaticCall })
+	require.Len(t, staticCall, 1)
+	path := staticCall[0].Path()
+	require.Len(t, path, 5)
+	require.Equal(t, 4, staticCall[0].Depth)
+	require.Equal(t, root, path[0])
+
+	refunded := root.Find(func(n *Node) bool { return n.Call.To == refund })[0]
+	require.True(t, refunded.Reverted())
+	require.False(t, refunded.Parent.Parent.Reverted())
+}
+
+func TestBuildDepth(t *testing.T) {
+	tx := bundle()
+	// with depths the static call is attributed to the pair instead
+	for i, depth := range []int{1, 1, 2, 3, 2, 1, 1, 2} {
+		tx.InternalTransactions[i].Depth = depth
+	}
+	require.Equal(t, []int{-1, -1, 1, 2, 1, -1, -1, 6}, shape(Build(tx)))
+
+	for i, address := range [][]int{{0}, {1}, {1, 0}, {1, 0, 0}, {1, 1}, {2}, {3}, {3, 0}} {
+		tx.InternalTransactions[i].Depth = 0
+		tx.InternalTransactions[i].TraceAddress = address
+	}
+	require.Equal(t, []int{-1, -1, 1, 2, 1, -1, -1, 6}, shape(Build(tx)))
+}
+
+func TestFlows(t *testing.T) {
+	root := Build(bundle())
+	transfers := root.Transfers()
+	require.Len(t, transfers, 2)
+	require.Equal(t, weth, transfers[0].Call.To)
+	require.Equal(t, builder, transfers[1].Call.To)
+
+	flows := Flows(root)
+	require.Len(t, flows, 3)
+	require.Equal(t, builder, flows[0].Address)
+	require.Equal(t, "50000000000000000", flows[0].Net().String())
+	require.Equal(t, bot, flows[1].Address)
+	require.Equal(t, "1050000000000000000", flows[1].Out.String())
+	require.Equal(t, "-1050000000000000000", flows[1].Net().String())
+	require.Equal(t, weth, flows[2].Address)
+	require.Equal(t, "1000000000000000000", flows[2].In.String())
+}
+
+func TestFprint(t *testing.T) {
+	tx := bundle()
+	tx.InternalTransactions = tx.InternalTransactions[:4]
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, Build(tx)))
+	require.Equal(t, `CALL `+searcher+` -> `+bot+` execute
+├── CALL `+bot+` -> `+weth+` 1 ETH 0xd0e30db0
+└── CALL `+bot+` -> `+pair+` 0x022c0d9f
+    └── CALL `+pair+` -> `+usdc+` transfer
+        └── DELEGATECALL `+usdc+` -> `+impl+` transfer
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, FprintFlows(&buf, Flows(Build(bundle()))))
+	require.Contains(t, buf.String(), bot+" in 0 out 1.05 net -1.05 ETH\n")
+	require.Contains(t, buf.String(), weth+" in 1 out 0 net +1 ETH\n")
+}
@@ -0,0 +1,66 @@
+package calltree
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// Flow is the ether an address sent and received across the calls of a transaction
+type Flow struct {
+	Address string
+	In      *big.Int
+	Out     *big.Int
+}
+
+// Net returns the ether received less the ether sent
+func (f Flow) Net() *big.Int {
+	return new(big.Int).Sub(f.In, f.Out)
+}
+
+// Value returns the ether sent by the call, 0 for calls which move none
+// such as delegated and static calls
+func (n *Node) Value() *big.Int {
+	switch n.Call.Type {
+	case TypeDelegateCall, TypeStaticCall:
+		return new(big.Int)
+	}
+	v, ok := client.ParseAmount(n.Call.Value)
+	if !ok {
+		return new(big.Int)
+	}
+	return v
+}
+
+// Transfers returns the calls under n which moved ether and were not reverted
+func (n *Node) Transfers() []*Node {
+	return n.Find(func(node *Node) bool {
+		return node.Value().Sign() > 0 && !node.Reverted()
+	})
+}
+
+// Flows returns the ether moved between addresses by the calls under n
+// which were not reverted, ordered by address
+func Flows(n *Node) []Flow {
+	flows := make(map[string]*Flow)
+	flow := func(address string) *Flow {
+		key := strings.ToLower(address)
+		if _, ok := flows[key]; !ok {
+			flows[key] = &Flow{Address: key, In: new(big.Int), Out: new(big.Int)}
+		}
+		return flows[key]
+	}
+	for _, node := range n.Transfers() {
+		value := node.Value()
+		flow(node.Call.From).Out.Add(flow(node.Call.From).Out, value)
+		flow(node.Call.To).In.Add(flow(node.Call.To).In, value)
+	}
+	out := make([]Flow, 0, len(flows))
+	for _, f := range flows {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
@@ -0,0 +1,68 @@
+package calltree
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// String returns a line describing the call: its type, sender, recipient,
+// ether sent, method and revert reason
+func (n *Node) String() string {
+	parts := []string{n.Call.Type, n.Call.From, "->", n.Call.To}
+	if value := n.Value(); value.Sign() > 0 {
+		parts = append(parts, formatEther(value)+" ETH")
+	}
+	if method := n.MethodName(); method != "" {
+		parts = append(parts, method)
+	}
+	if n.Call.ErrorReason != "" {
+		parts = append(parts, "[reverted: "+n.Call.ErrorReason+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// Fprint writes the tree under n with a line for every call
+func Fprint(w io.Writer, n *Node) error {
+	if _, err := fmt.Fprintln(w, n); err != nil {
+		return err
+	}
+	return fprintChildren(w, n, "")
+}
+
+func fprintChildren(w io.Writer, n *Node, indent string) error {
+	for i, child := range n.Children {
+		branch, next := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			branch, next = "└── ", "    "
+		}
+		if _, err := fmt.Fprintln(w, indent+branch+child.String()); err != nil {
+			return err
+		}
+		if err := fprintChildren(w, child, indent+next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FprintFlows writes a line for every flow with the ether received, sent and the net change
+func FprintFlows(w io.Writer, flows []Flow) error {
+	for _, f := range flows {
+		net := formatEther(f.Net())
+		if f.Net().Sign() > 0 {
+			net = "+" + net
+		}
+		if _, err := fmt.Fprintf(w, "%s in %s out %s net %s ETH\n", f.Address, formatEther(f.In), formatEther(f.Out), net); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatEther(wei *big.Int) string {
+	return client.ScaleAmount(wei, client.EtherDecimals).Text('f', -1)
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EtherDecimals is the number of decimals of ether amounts
+const EtherDecimals = 18
+
+// amountJSON decodes amounts sent as decimal strings or numbers
+type amountJSON struct {
+	v **big.Int
+}
+
+func (a amountJSON) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(bytes.TrimSpace(data), `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*a.v = nil
+		return nil
+	}
+	v, ok := ParseAmount(string(data))
+	if !ok {
+		return errors.Errorf("invalid amount:%s", data)
+	}
+	*a.v = v
+	return nil
+}
+
+// UnmarshalJSON decodes the delta sent as a decimal string
+func (b *BalanceChange) UnmarshalJSON(data []byte) error {
+	type plain BalanceChange
+	raw := struct {
+		*plain
+		Delta amountJSON `json:"delta"`
+	}{plain: (*plain)(b), Delta: amountJSON{&b.Delta}}
+	return json.Unmarshal(data, &raw)
+}
+
+// MarshalJSON encodes the delta as a decimal string as sent by the api
+func (b BalanceChange) MarshalJSON() ([]byte, error) {
+	type plain BalanceChange
+	return json.Marshal(struct {
+		plain
+		Delta string `json:"delta"`
+	}{plain: plain(b), Delta: AmountString(b.Delta)})
+}
+
+// UnmarshalJSON decodes the amount sent as a decimal string
+func (b *Breakdown) UnmarshalJSON(data []byte) error {
+	type plain Breakdown
+	raw := struct {
+		*plain
+		Amount amountJSON `json:"amount"`
+	}{plain: (*plain)(b), Amount: amountJSON{&b.Amount}}
+	return json.Unmarshal(data, &raw)
+}
+
+// MarshalJSON encodes the amount as a decimal string as sent by the api
+func (b Breakdown) MarshalJSON() ([]byte, error) {
+	type plain Breakdown
+	return json.Marshal(struct {
+		plain
+		Amount string `json:"amount"`
+	}{plain: plain(b), Amount: AmountString(b.Amount)})
+}
+
+// ParseAmount parses an amount sent by the api as a decimal or 0x prefixed hex integer
+func ParseAmount(s string) (*big.Int, bool) {
+	if s == "" {
+		return nil, false
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return new(big.Int).SetString(s[2:], 16)
+	}
+	return new(big.Int).SetString(s, 10)
+}
+
+// AmountString returns the decimal form of v, empty if it is not set
+func AmountString(v *big.Int) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
+
+// IsEther reports whether the asset is the native asset
+func (a Asset) IsEther() bool {
+	return a.Type == "ether"
+}
+
+// Matches reports whether asset identifies a, by contract address, by
+// symbol, or "ether" for the native asset. Comparisons are case insensitive.
+func (a Asset) Matches(asset string) bool {
+	switch {
+	case asset == "":
+		return false
+	case a.ContractAddress != "" && strings.EqualFold(a.ContractAddress, asset):
+		return true
+	case a.IsEther() && strings.EqualFold(asset, "ether"):
+		return true
+	}
+	return strings.EqualFold(a.Symbol, asset)
+}
+
+// ScaleAmount converts an amount in the smallest unit of an asset into whole units
+func ScaleAmount(amount *big.Int, decimals int) *big.Float {
+	out := new(big.Float)
+	if amount == nil {
+		return out
+	}
+	out.SetInt(amount)
+	if decimals <= 0 {
+		return out
+	}
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	return out.Quo(out, scale)
+}
+
+// AssetDecimals returns the decimals of asset, taken from the asset itself
+// or the contract calls of the transaction to its contract
+func (t TransactionPayload) AssetDecimals(asset Asset) (int, bool) {
+	if asset.IsEther() {
+		return EtherDecimals, true
+	}
+	if asset.Decimals > 0 {
+		return asset.Decimals, true
+	}
+	for _, itx := range t.InternalTransactions {
+		call := itx.ContractCall
+		if call.ContractDecimals > 0 && call.ContractAddress != "" && strings.EqualFold(call.ContractAddress, asset.ContractAddress) {
+			return call.ContractDecimals, true
+		}
+	}
+	return 0, false
+}
+
+// DeltaFor returns the net change of the balance of asset held by address,
+// zero if the transaction does not change it. The asset is identified as
+// described by Asset.Matches.
+func (t TransactionPayload) DeltaFor(address, asset string) *big.Int {
+	total := new(big.Int)
+	for _, change := range t.NetBalanceChanges {
+		if !strings.EqualFold(change.Address, address) {
+			continue
+		}
+		for _, bc := range change.BalanceChanges {
+			if bc.Delta != nil && bc.Asset.Matches(asset) {
+				total.Add(total, bc.Delta)
+			}
+		}
+	}
+	return total
+}
+
+// AssetDelta is the net change of the balance of an asset held by an address
+type AssetDelta struct {
+	Address string
+	Asset   Asset
+	// Delta is in the smallest unit of the asset
+	Delta *big.Int
+	// Decimals of the asset, -1 if unknown
+	Decimals int
+}
+
+// Amount returns the delta in whole units of the asset, or in its smallest unit when the decimals are unknown
+func (d AssetDelta) Amount() *big.Float {
+	return ScaleAmount(d.Delta, d.Decimals)
+}
+
+// AssetDeltas returns the net balance changes of the transaction per
+// address and asset. Token decimals are taken from decimals, keyed by
+// lowercase contract address, falling back to AssetDecimals.
+func AssetDeltas(tx TransactionPayload, decimals map[string]int) []AssetDelta {
+	overrides := make(map[string]int, len(decimals))
+	for contract, d := range decimals {
+		overrides[strings.ToLower(contract)] = d
+	}
+	var deltas []AssetDelta
+	for _, change := range tx.NetBalanceChanges {
+		for _, bc := range change.BalanceChanges {
+			d := AssetDelta{Address: change.Address, Asset: bc.Asset, Delta: bc.Delta, Decimals: -1}
+			if d.Delta == nil {
+				d.Delta = new(big.Int)
+			}
+			if n, ok := overrides[strings.ToLower(bc.Asset.ContractAddress)]; ok && !bc.Asset.IsEther() {
+				d.Decimals = n
+			} else if n, ok := tx.AssetDecimals(bc.Asset); ok {
+				d.Decimals = n
+			}
+			deltas = append(deltas, d)
+		}
+	}
+	return deltas
+}
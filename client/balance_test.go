@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceChanges(t *testing.T) {
+	var tx TransactionPayload
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"internalTransactions": [{"contractCall": {"contractAddress": "0xUSDC", "contractDecimals": 6}}],
+		"netBalanceChanges": [
+			{"address": "0xAA", "balanceChanges": [
+				{"delta": "-1500000000000000000", "asset": {"type": "ether", "symbol": "ETH"},
+				 "breakdown": [{"counterparty": "0xrouter", "amount": "-1500000000000000000"}]},
+				{"delta": "2500000000", "asset": {"type": "erc20", "symbol": "USDC", "contractAddress": "0xusdc"}}
+			]},
+			{"address": "0xrouter", "balanceChanges": [
+				{"delta": "1500000000000000000", "asset": {"type": "ether", "symbol": "ETH"}}
+			]}
+		]
+	}`), &tx))
+
+	change := tx.NetBalanceChanges[0].BalanceChanges[0]
+	require.Equal(t, "-1500000000000000000", change.Delta.String())
+	require.Equal(t, "-1500000000000000000", change.Breakdown[0].Amount.String())
+
+	require.Equal(t, big.NewInt(2500000000), tx.DeltaFor("0xaa", "0xUSDC"))
+	require.Equal(t, big.NewInt(2500000000), tx.DeltaFor("0xaa", "usdc"))
+	require.Equal(t, "-1500000000000000000", tx.DeltaFor("0xaa", "ether").String())
+	require.Equal(t, "1500000000000000000", tx.DeltaFor("0xrouter", "ETH").String())
+	require.Zero(t, tx.DeltaFor("0xbb", "ETH").Sign())
+
+	decimals, ok := tx.AssetDecimals(tx.NetBalanceChanges[0].BalanceChanges[1].Asset)
+	require.True(t, ok)
+	require.Equal(t, 6, decimals)
+	_, ok = tx.AssetDecimals(Asset{Type: "erc20", ContractAddress: "0xother"})
+	require.False(t, ok)
+	require.Equal(t, "2500.00", ScaleAmount(tx.DeltaFor("0xaa", "usdc"), decimals).Text('f', 2))
+
+	// amounts are encoded as strings as sent by the api
+	data, err := json.Marshal(change)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"delta": "-1500000000000000000",
+		"asset": {"type": "ether", "symbol": "ETH", "contractAddress": ""},
+		"breakdown": [{"counterparty": "0xrouter", "amount": "-1500000000000000000"}]
+	}`, string(data))
+	var decoded BalanceChange
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, change, decoded)
+
+	// leading zeros are decimal, not octal
+	require.NoError(t, json.Unmarshal([]byte(`{"delta": "010"}`), &decoded))
+	require.Equal(t, big.NewInt(10), decoded.Delta)
+	require.Error(t, json.Unmarshal([]byte(`{"delta": "nope"}`), &decoded))
+}
+
+func TestParseAmount(t *testing.T) {
+	v, ok := ParseAmount("1500000000000000000")
+	require.True(t, ok)
+	require.Equal(t, "1500000000000000000", AmountString(v))
+	v, ok = ParseAmount("0x14d1120d7b160000")
+	require.True(t, ok)
+	require.Equal(t, "1500000000000000000", AmountString(v))
+	// leading zeros are decimal rather than octal
+	v, ok = ParseAmount("010")
+	require.True(t, ok)
+	require.Equal(t, "10", AmountString(v))
+	_, ok = ParseAmount("")
+	require.False(t, ok)
+	_, ok = ParseAmount("1.5")
+	require.False(t, ok)
+	require.Empty(t, AmountString(nil))
+}
@@ -2,14 +2,19 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"log"
 	"net/url"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Opts provides configuration over the websocket connection
@@ -19,8 +24,24 @@ type Opts struct {
 	Path                 string
 	APIKey               string
 	PrintConnectResponse bool
+	// Metrics is used to instrument the client, nil disables instrumentation
+	Metrics *Metrics
+	// TracerProvider is used to create spans, nil disables tracing
+	TracerProvider trace.TracerProvider
+	// History records subscription messages, any messages it already holds
+	// are restored when the client is initialized
+	History History
+	// MaxReconnects is the number of times Listen tries to reconnect when
+	// reading from the connection fails, Listen returns the error if 0
+	MaxReconnects int
+	// ReconnectBackoff is the delay before the first reconnect attempt,
+	// doubling on every attempt. DefaultReconnectBackoff is used if 0.
+	ReconnectBackoff time.Duration
 }
 
+// DefaultReconnectBackoff is the delay before the first reconnect attempt
+const DefaultReconnectBackoff = time.Second
+
 // ConnectResponse is the message we receive when opening a connection to the API
 type ConnectResponse struct {
 	ConnectionID  string `json:"connectionId"`
@@ -31,62 +52,159 @@ type ConnectResponse struct {
 	Version       int    `json:"version"`
 }
 
+// ackTimeout bounds the wait for the acknowledgement of a configuration
+const ackTimeout = 30 * time.Second
+
 // Client wraps gorilla websocket connections
 type Client struct {
-	conn    *websocket.Conn
-	ctx     context.Context
-	cancel  context.CancelFunc
-	initMsg BaseMessage // used to resend the initialization msg if connection drops
-	apiKey  string
-	mtx     sync.RWMutex
+	conn      *websocket.Conn
+	connResp  ConnectResponse
+	connected atomic.Bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	opts      Opts
+	initMsg   *BaseMessage // used to resend the initialization msg if connection drops
+	apiKey    string
+	mtx       sync.RWMutex // guards conn and writes to it
+	readMtx   sync.Mutex   // serializes reads from conn
+	subs      *subscriptions
+	history   History
+	restored  bool // whether the history has been restored
+	metrics   *Metrics
+	tracer    trace.Tracer
+	listening atomic.Bool
+	// acks hands the acknowledgements read by Listen over to configure
+	acks     chan ConnectResponse
+	ackMtx   sync.Mutex // serializes waiting for acknowledgements
+	awaiting atomic.Bool
+}
+
+// Status describes the state of the client's connection
+type Status struct {
+	Connected     bool   `json:"connected"`
+	Initialized   bool   `json:"initialized"`
+	ConnectionID  string `json:"connectionId"`
+	ServerVersion string `json:"serverVersion"`
+	System        string `json:"system,omitempty"`
+	Network       string `json:"network,omitempty"`
 }
 
+// Handler processes a single event received from the api. When tracing
+// is enabled ctx carries the span created for the event's dispatch.
+type Handler func(ctx context.Context, msg *EthTxPayload) error
+
 // New returns a new blocknative websocket client
 func New(ctx context.Context, opts Opts) (*Client, error) {
 	ctx, cancel := context.WithCancel(ctx)
+	conn, resp, err := dial(ctx, opts)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	provider := opts.TracerProvider
+	if provider == nil {
+		provider = trace.NewNoopTracerProvider()
+	}
+	c := &Client{
+		conn:     conn,
+		connResp: resp,
+		ctx:      ctx,
+		cancel:   cancel,
+		opts:     opts,
+		apiKey:   opts.APIKey,
+		subs:     newSubscriptions(),
+		history:  opts.History,
+		metrics:  opts.Metrics,
+		tracer:   provider.Tracer(tracerName),
+		acks:     make(chan ConnectResponse, 1),
+	}
+	c.connected.Store(true)
+	return c, nil
+}
+
+// dial opens a websocket connection to the api and checks
+// that the connection was accepted
+func dial(ctx context.Context, opts Opts) (*websocket.Conn, ConnectResponse, error) {
 	u := url.URL{
 		Scheme: opts.Scheme,
 		Host:   opts.Host,
 		Path:   opts.Path,
 	}
+	var out ConnectResponse
 	c, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
 	if err != nil {
-		cancel()
-		return nil, err
+		return nil, out, err
 	}
 	// this checks out connection to blocknative's api and makes sure that we connected properly
-	var out ConnectResponse
 	if err := c.ReadJSON(&out); err != nil {
-		cancel()
-		return nil, err
+		c.Close()
+		return nil, out, err
 	}
 	if out.Status != "ok" {
-		cancel()
-		return nil, errors.Errorf("failed to initialize websockets connection reason: %s", out.Reason)
+		c.Close()
+		return nil, out, errors.Errorf("failed to initialize websockets connection reason: %s", out.Reason)
 	}
 	if opts.PrintConnectResponse {
 		log.Printf("%+v\n", out)
 	}
-	return &Client{conn: c, ctx: ctx, cancel: cancel, apiKey: opts.APIKey}, nil
+	return c, out, nil
 }
 
 // Initialize is used to handle blocknative websockets api initialization
 // note we set CategoryCode and EventCode ourselves.
 func (c *Client) Initialize(msg BaseMessage) error {
+	c.readMtx.Lock()
+	defer c.readMtx.Unlock()
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 	msg.Version = "1"
 	msg.CategoryCode = "initialize"
 	msg.EventCode = "checkDappId"
-	c.initMsg = msg
+	c.initMsg = &msg
+	if err := c.initialize(c.ctx, msg); err != nil {
+		return err
+	}
+	return c.restore()
+}
+
+// restore re-establishes the subscriptions recorded in the history
+// the first time the client is initialized
+func (c *Client) restore() error {
+	if c.history == nil || c.restored {
+		return nil
+	}
+	if err := c.history.Compact(); err != nil {
+		return errors.Wrap(err, "compacting message history")
+	}
+	msgs, err := c.history.Messages()
+	if err != nil {
+		return errors.Wrap(err, "reading message history")
+	}
+	for _, msg := range msgs {
+		// persisted histories leave out the api key
+		c.subs.track(withDappID(msg, c.apiKey))
+	}
+	if err := c.resubscribe(); err != nil {
+		return err
+	}
+	c.restored = true
+	c.metrics.setWatched(c.subs)
+	return nil
+}
+
+func (c *Client) initialize(ctx context.Context, msg BaseMessage) (err error) {
+	_, span := c.tracer.Start(ctx, "blocknative.initialize", trace.WithAttributes(baseAttributes(msg)...))
+	defer func() { endSpan(span, err) }()
+	start := time.Now()
 	if err := c.conn.WriteJSON(&msg); err != nil {
 		return err
 	}
 	var out ConnectResponse
-	err := c.conn.ReadJSON(&out)
+	err = c.conn.ReadJSON(&out)
 	if err != nil {
 		return err
 	}
+	c.metrics.observeAck("initialize", start)
 	if out.Status != "ok" {
 		return errors.Errorf("failed to initialize api connection reason:%v", out.Reason)
 	}
@@ -95,36 +213,429 @@ func (c *Client) Initialize(msg BaseMessage) error {
 
 // EventSub creates an event subscription.
 func (c *Client) EventSub(msg Configuration) error {
+	return c.EventSubContext(c.ctx, msg)
+}
+
+// EventSubContext creates an event subscription, tracing it as part of ctx
+func (c *Client) EventSubContext(ctx context.Context, msg Configuration) error {
+	c.readMtx.Lock()
+	defer c.readMtx.Unlock()
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
-	if err := c.conn.WriteJSON(&msg); err != nil {
+	if err := c.eventSub(ctx, msg); err != nil {
 		return err
 	}
+	return c.record(msg)
+}
 
-	var out ConnectResponse
-	err := c.conn.ReadJSON(&out)
-	if err != nil {
+func (c *Client) eventSub(ctx context.Context, msg Configuration) (err error) {
+	_, attrs, _ := messageSpan(msg)
+	_, span := c.tracer.Start(ctx, "blocknative.event_sub", trace.WithAttributes(attrs...))
+	defer func() { endSpan(span, err) }()
+	start := time.Now()
+	if err := c.conn.WriteJSON(&msg); err != nil {
 		return err
 	}
+	return c.readAck(c.conn, "event_sub", start)
+}
+
+// readAck reads the acknowledgement of a message written to conn at start,
+// skipping any events received before it
+func (c *Client) readAck(conn *websocket.Conn, method string, start time.Time) error {
+	var out EthTxPayload
+	for {
+		out = EthTxPayload{}
+		if err := conn.ReadJSON(&out); err != nil {
+			return err
+		}
+		if out.Event.EventCode == "" {
+			break
+		}
+	}
+	c.metrics.observeAck(method, start)
 	if out.Status != "ok" {
 		return errors.Errorf("failed to create subscription reason:%v", out.Reason)
 	}
-
 	return nil
 }
 
 // ReadJSON is a wrapper around Conn:ReadJSON
 func (c *Client) ReadJSON(out interface{}) error {
+	c.readMtx.Lock()
+	defer c.readMtx.Unlock()
 	c.mtx.RLock()
-	defer c.mtx.RUnlock()
-	return c.conn.ReadJSON(out)
+	conn := c.conn
+	c.mtx.RUnlock()
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		c.connected.Store(false)
+		return err
+	}
+	received := time.Now()
+	if err := json.Unmarshal(data, out); err != nil {
+		c.metrics.observeDecodeError()
+		return err
+	}
+	if msg, ok := out.(*EthTxPayload); ok {
+		c.metrics.observeEvent(msg, received)
+	}
+	return nil
 }
 
 // WriteJSON is a wrapper around Conn:WriteJSON
 func (c *Client) WriteJSON(out interface{}) error {
+	return c.send(c.ctx, out)
+}
+
+// send writes msg to the connection, tracing and tracking it when it is a
+// subscription message. Subscription messages wait for their acknowledgement.
+func (c *Client) send(ctx context.Context, msg interface{}) error {
+	var err error
+	switch _, _, ok := historyOp(msg); {
+	case !ok:
+		err = c.write(ctx, msg)
+	case c.listening.Load():
+		err = c.awaitAck(ctx, msg, "watch")
+	default:
+		err = c.writeAck(ctx, msg, "watch")
+	}
+	if err != nil {
+		return err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.record(msg)
+}
+
+// write writes msg to the connection, tracing it when it is a subscription message
+func (c *Client) write(ctx context.Context, msg interface{}) (err error) {
+	if name, attrs, ok := messageSpan(msg); ok {
+		var span trace.Span
+		_, span = c.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+		defer func() { endSpan(span, err) }()
+	}
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
-	return c.conn.WriteJSON(out)
+	return c.conn.WriteJSON(msg)
+}
+
+// configure sends a configuration and waits for the api to acknowledge it
+func (c *Client) configure(ctx context.Context, msg Configuration) error {
+	if !c.listening.Load() {
+		return c.EventSubContext(ctx, msg)
+	}
+	if err := c.awaitAck(ctx, msg, "event_sub"); err != nil {
+		return err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.record(msg)
+}
+
+// writeAck writes msg and reads its acknowledgement while Listen is not running
+func (c *Client) writeAck(ctx context.Context, msg interface{}, method string) error {
+	c.readMtx.Lock()
+	defer c.readMtx.Unlock()
+	start := time.Now()
+	if err := c.write(ctx, msg); err != nil {
+		return err
+	}
+	c.mtx.RLock()
+	conn := c.conn
+	c.mtx.RUnlock()
+	return c.readAck(conn, method, start)
+}
+
+// awaitAck writes msg and waits for Listen to hand over its acknowledgement.
+// The api acknowledges messages in order without saying which message an
+// acknowledgement is for, so subscription messages are written one at a time
+// and each waits for its acknowledgement before the next is written.
+func (c *Client) awaitAck(ctx context.Context, msg interface{}, method string) error {
+	c.ackMtx.Lock()
+	defer c.ackMtx.Unlock()
+	// drop any acknowledgement left over from a configuration which timed out
+	select {
+	case <-c.acks:
+	default:
+	}
+	c.awaiting.Store(true)
+	defer c.awaiting.Store(false)
+	start := time.Now()
+	if err := c.write(ctx, msg); err != nil {
+		return err
+	}
+	timer := time.NewTimer(ackTimeout)
+	defer timer.Stop()
+	select {
+	case out := <-c.acks:
+		c.metrics.observeAck(method, start)
+		if out.Status != "ok" {
+			return errors.Errorf("failed to create subscription reason:%v", out.Reason)
+		}
+		return nil
+	case <-timer.C:
+		return errors.New("timed out waiting for acknowledgement")
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+}
+
+// ack hands an acknowledgement read by Listen over to awaitAck when it is waiting for one
+func (c *Client) ack(msg *EthTxPayload) {
+	if msg.Status == "" || !c.awaiting.Load() {
+		return
+	}
+	select {
+	case c.acks <- ConnectResponse{Status: msg.Status, Reason: msg.Reason}:
+	default:
+	}
+}
+
+// record updates the active state and message history with a message sent to the api
+func (c *Client) record(msg interface{}) error {
+	if !c.subs.track(msg) {
+		return nil
+	}
+	c.metrics.setWatched(c.subs)
+	if c.history == nil {
+		return nil
+	}
+	return errors.Wrap(c.history.Record(msg), "recording message history")
+}
+
+// WatchAddress subscribes to events for address on the network the client was initialized with
+func (c *Client) WatchAddress(ctx context.Context, address string) error {
+	base, err := c.baseMessage()
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, NewAddressSubscribe(base, address))
+}
+
+// UnwatchAddress unsubscribes from events for address
+func (c *Client) UnwatchAddress(ctx context.Context, address string) error {
+	base, err := c.baseMessage()
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, NewAddressUnsubscribe(base, address))
+}
+
+// WatchTx subscribes to events for the transaction with the given hash
+func (c *Client) WatchTx(ctx context.Context, txHash string) error {
+	base, err := c.baseMessage()
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, NewTxSubscribe(base, txHash))
+}
+
+// UnwatchTx unsubscribes from events for the transaction with the given hash
+func (c *Client) UnwatchTx(ctx context.Context, txHash string) error {
+	base, err := c.baseMessage()
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, NewTxUnsubscribe(base, txHash))
+}
+
+// SetConfig sends a configuration for cfg.Scope and waits for the api to
+// acknowledge it, unlike EventSub it is safe to use while Listen is running
+func (c *Client) SetConfig(ctx context.Context, cfg Config) error {
+	base, err := c.baseMessage()
+	if err != nil {
+		return err
+	}
+	return c.configure(ctx, NewConfiguration(base, cfg))
+}
+
+// RemoveConfig drops the configuration for scope from the active state. The
+// api has no way of deleting a configuration, so the scope is reset to a
+// configuration without filters or abis.
+func (c *Client) RemoveConfig(ctx context.Context, scope string) error {
+	base, err := c.baseMessage()
+	if err != nil {
+		return err
+	}
+	if err := c.configure(ctx, NewConfiguration(base, Config{Scope: scope})); err != nil {
+		return err
+	}
+	// record the removal locally, this message is never sent to the api
+	removal := NewConfiguration(base, Config{Scope: scope})
+	removal.EventCode = "unwatch"
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.record(removal)
+}
+
+// Subscriptions returns a snapshot of the watches active on the connection
+func (c *Client) Subscriptions() Subscriptions {
+	return c.subs.snapshot()
+}
+
+// Status returns the state of the connection along with the details
+// received from the api when it was opened
+func (c *Client) Status() Status {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	status := Status{
+		Connected:     c.connected.Load(),
+		Initialized:   c.initMsg != nil,
+		ConnectionID:  c.connResp.ConnectionID,
+		ServerVersion: c.connResp.ServerVersion,
+	}
+	if c.initMsg != nil {
+		status.System = c.initMsg.System
+		status.Network = c.initMsg.Network
+	}
+	return status
+}
+
+// baseMessage returns a copy of the initialization message with
+// an updated timestamp for use in subsequent messages
+func (c *Client) baseMessage() (BaseMessage, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	if c.initMsg == nil {
+		return BaseMessage{}, errors.New("client not initialized")
+	}
+	base := *c.initMsg
+	base.Timestamp = time.Now()
+	return base, nil
+}
+
+// Listen reads events from the connection and dispatches each one to handler.
+// Messages which can't be decoded or don't carry an event are skipped, other
+// than the acknowledgements of subscription messages. When reading fails the
+// connection is re-established up to Opts.MaxReconnects times. It returns
+// when ctx is done, reconnecting fails or handler returns an error.
+func (c *Client) Listen(ctx context.Context, handler Handler) error {
+	c.listening.Store(true)
+	defer c.listening.Store(false)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var msg EthTxPayload
+		if err := c.ReadJSON(&msg); err != nil {
+			if isDecodeError(err) {
+				continue
+			}
+			if err := c.reconnect(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Event.EventCode == "" {
+			c.ack(&msg)
+			continue
+		}
+		if err := c.dispatch(ctx, &msg, handler); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch hands msg to handler within a span describing the event
+func (c *Client) dispatch(ctx context.Context, msg *EthTxPayload, handler Handler) (err error) {
+	ctx, span := c.tracer.Start(ctx, "blocknative.event",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(eventAttributes(msg)...),
+	)
+	defer func() { endSpan(span, err) }()
+	return handler(ctx, msg)
+}
+
+func isDecodeError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// reconnect re-establishes the connection after reading failed with
+// readErr, returning readErr if every attempt fails or the client is closed
+func (c *Client) reconnect(ctx context.Context, readErr error) error {
+	backoff := c.opts.ReconnectBackoff
+	if backoff <= 0 {
+		backoff = DefaultReconnectBackoff
+	}
+	for attempt := 0; attempt < c.opts.MaxReconnects; attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return readErr
+		}
+		backoff *= 2
+		err := c.Reconnect()
+		if err == nil {
+			return nil
+		}
+		log.Printf("reconnect failed attempt:%v reason:%v", attempt+1, err)
+	}
+	return readErr
+}
+
+// Reconnect re-dials the api, resends the initialization message and
+// re-establishes all active subscriptions on the new connection
+func (c *Client) Reconnect() error {
+	c.readMtx.Lock()
+	defer c.readMtx.Unlock()
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	conn, resp, err := dial(c.ctx, c.opts)
+	if err != nil {
+		return err
+	}
+	c.conn.Close()
+	c.conn = conn
+	c.connResp = resp
+	c.connected.Store(true)
+	if c.initMsg != nil {
+		if err := c.initialize(c.ctx, *c.initMsg); err != nil {
+			return errors.Wrap(err, "resending initialization message")
+		}
+		if err := c.resubscribe(); err != nil {
+			return err
+		}
+	}
+	c.metrics.observeReconnect()
+	return nil
+}
+
+// resubscribe sends the currently active subscriptions over the connection
+func (c *Client) resubscribe() error {
+	c.subs.mx.RLock()
+	defer c.subs.mx.RUnlock()
+	base := *c.initMsg
+	base.Timestamp = time.Now()
+	for _, cfg := range c.subs.configs {
+		if err := c.eventSub(c.ctx, NewConfiguration(base, cfg)); err != nil {
+			return errors.Wrapf(err, "restoring config scope:%v", cfg.Scope)
+		}
+	}
+	for addr := range c.subs.addresses {
+		start := time.Now()
+		if err := c.conn.WriteJSON(NewAddressSubscribe(base, addr)); err != nil {
+			return errors.Wrapf(err, "restoring address subscription:%v", addr)
+		}
+		if err := c.readAck(c.conn, "watch", start); err != nil {
+			return errors.Wrapf(err, "restoring address subscription:%v", addr)
+		}
+	}
+	for hash := range c.subs.txHashes {
+		start := time.Now()
+		if err := c.conn.WriteJSON(NewTxSubscribe(base, hash)); err != nil {
+			return errors.Wrapf(err, "restoring tx subscription:%v", hash)
+		}
+		if err := c.readAck(c.conn, "watch", start); err != nil {
+			return errors.Wrapf(err, "restoring tx subscription:%v", hash)
+		}
+	}
+	return nil
 }
 
 // APIKey returns the api key being used by the client
@@ -132,30 +643,23 @@ func (c *Client) APIKey() string {
 	return c.apiKey
 }
 
-// Close is used to terminate our websocket client
+// Close is used to terminate our websocket client, closing the connection
+// and the history when it can be closed, such as a FileHistory
 func (c *Client) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.connected.Store(false)
 	err := c.conn.WriteMessage(
 		websocket.CloseMessage,
 		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
 	)
 	c.cancel()
-	return err
-}
-
-func NetName(id int64) (string, error) {
-	var netName string
-	switch id {
-	case 1:
-		netName = "main"
-	case 4:
-		netName = "rinkeby"
-	case 5:
-		netName = "goerli"
-
-	default:
-		return "", errors.Errorf("network not supported id:%v", id)
+	if closer, ok := c.history.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
 	}
-	return netName, nil
+	return err
 }
 
 func ParseGas(msg *EthTxPayload) (gasBaseFeeGwei, gasTipGwei float64, err error) {
@@ -1,6 +1,27 @@
 package client
 
-import "sync"
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// History stores the subscription messages sent to the api such that
+// the session can be re-established after a connection drop or restart
+type History interface {
+	// Record appends a message to the history
+	Record(msg interface{}) error
+	// Messages returns every message in the history in the order they were pushed
+	Messages() ([]interface{}, error)
+	// Compact drops messages which no longer affect the session
+	Compact() error
+}
 
 // MsgHistory is used to store a copy of all messages we send
 // such that in the event of connection drops we can re-establish
@@ -17,6 +38,12 @@ func (mg *MsgHistory) Push(msg interface{}) {
 	mg.buffer = append(mg.buffer, msg)
 }
 
+// Record pushes msg onto the buffer, it never fails
+func (mg *MsgHistory) Record(msg interface{}) error {
+	mg.Push(msg)
+	return nil
+}
+
 // Pop is used to pop a message out of the buffer
 func (mg *MsgHistory) Pop() interface{} {
 	mg.mx.Lock()
@@ -44,9 +71,292 @@ func (mg *MsgHistory) PopAll() []interface{} {
 	return copied
 }
 
+// Messages returns all elements from the buffer without removing them
+func (mg *MsgHistory) Messages() ([]interface{}, error) {
+	mg.mx.RLock()
+	defer mg.mx.RUnlock()
+	copied := make([]interface{}, len(mg.buffer))
+	copy(copied, mg.buffer)
+	return copied, nil
+}
+
+// Compact drops watch/unwatch pairs and replaced configs from the buffer
+func (mg *MsgHistory) Compact() error {
+	mg.mx.Lock()
+	defer mg.mx.Unlock()
+	mg.buffer = compact(mg.buffer)
+	return nil
+}
+
 // Len returns the length of the msg history buffewr
 func (mg *MsgHistory) Len() int {
 	mg.mx.RLock()
 	defer mg.mx.RUnlock()
 	return len(mg.buffer)
 }
+
+// FileHistory is a History persisted to an append-only file holding one
+// JSON encoded message per line. The file is compacted when opened.
+type FileHistory struct {
+	mx   sync.Mutex
+	path string
+	file *os.File
+	msgs []interface{}
+}
+
+// NewFileHistory opens the history stored at path, creating it if it doesn't exist
+func NewFileHistory(path string) (*FileHistory, error) {
+	msgs, err := readHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	fh := &FileHistory{path: path, msgs: msgs}
+	if err := fh.Compact(); err != nil {
+		return nil, err
+	}
+	return fh, nil
+}
+
+// HistorySubscriptions returns the subscriptions a connection restored from
+// the history stored at path would have, without modifying the file
+func HistorySubscriptions(path string) (Subscriptions, error) {
+	msgs, err := readHistory(path)
+	if err != nil {
+		return Subscriptions{}, err
+	}
+	subs := newSubscriptions()
+	for _, msg := range msgs {
+		subs.track(msg)
+	}
+	return subs.snapshot(), nil
+}
+
+// readHistory decodes the messages of the history file at path, a missing file holds none
+func readHistory(path string) ([]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrap(err, "reading history file")
+	}
+	var msgs []interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		msg, err := decodeHistoryMessage(line)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding history file")
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading history file")
+	}
+	return msgs, nil
+}
+
+// decodeHistoryMessage decodes a message into the type matching its category
+// code, messages of unknown categories are returned as json.RawMessage
+func decodeHistoryMessage(data []byte) (interface{}, error) {
+	var base BaseMessage
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+	switch base.CategoryCode {
+	case "accountAddress":
+		var m AddressSubscribe
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case "activeTransaction":
+		var m TxSubscribe
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case "configs":
+		var m Configuration
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return json.RawMessage(append([]byte(nil), data...)), nil
+	}
+}
+
+// Record appends msg to the history file, without the api key
+func (fh *FileHistory) Record(msg interface{}) error {
+	data, err := json.Marshal(withDappID(msg, ""))
+	if err != nil {
+		return err
+	}
+	fh.mx.Lock()
+	defer fh.mx.Unlock()
+	if _, err := fh.file.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "writing history file")
+	}
+	fh.msgs = append(fh.msgs, msg)
+	return nil
+}
+
+// Messages returns every message in the history
+func (fh *FileHistory) Messages() ([]interface{}, error) {
+	fh.mx.Lock()
+	defer fh.mx.Unlock()
+	copied := make([]interface{}, len(fh.msgs))
+	copy(copied, fh.msgs)
+	return copied, nil
+}
+
+// Compact drops watch/unwatch pairs and replaced configs, atomically rewriting the history file
+func (fh *FileHistory) Compact() error {
+	fh.mx.Lock()
+	defer fh.mx.Unlock()
+	msgs := compact(fh.msgs)
+	var buf bytes.Buffer
+	for _, msg := range msgs {
+		data, err := json.Marshal(withDappID(msg, ""))
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	}
+	tmp := filepath.Join(filepath.Dir(fh.path), "."+filepath.Base(fh.path)+".tmp")
+	// the api key is left out but the history still reveals what is watched
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return errors.Wrap(err, "writing history file")
+	}
+	if err := os.Rename(tmp, fh.path); err != nil {
+		return errors.Wrap(err, "writing history file")
+	}
+	file, err := os.OpenFile(fh.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "opening history file")
+	}
+	if fh.file != nil {
+		fh.file.Close()
+	}
+	fh.file = file
+	fh.msgs = msgs
+	return nil
+}
+
+// Close closes the underlying history file, closing it again does nothing
+func (fh *FileHistory) Close() error {
+	fh.mx.Lock()
+	defer fh.mx.Unlock()
+	if fh.file == nil {
+		return nil
+	}
+	err := fh.file.Close()
+	fh.file = nil
+	return err
+}
+
+// withDappID returns a copy of a subscription message with its api key
+// replaced by dappID, other messages are returned as is
+func withDappID(msg interface{}, dappID string) interface{} {
+	switch m := msg.(type) {
+	case *AddressSubscribe:
+		return withDappID(*m, dappID)
+	case *TxSubscribe:
+		return withDappID(*m, dappID)
+	case *Configuration:
+		return withDappID(*m, dappID)
+	case AddressSubscribe:
+		m.DappID = dappID
+		return m
+	case TxSubscribe:
+		m.DappID = dappID
+		return m
+	case Configuration:
+		m.DappID = dappID
+		return m
+	}
+	return msg
+}
+
+const (
+	historyWatch = iota
+	historyUnwatch
+	historyReplace
+)
+
+// historyOp returns the key a subscription message applies to and how
+// it affects earlier messages for the same key
+func historyOp(msg interface{}) (key string, op int, ok bool) {
+	switch m := msg.(type) {
+	case *AddressSubscribe:
+		return historyOp(*m)
+	case *TxSubscribe:
+		return historyOp(*m)
+	case *Configuration:
+		return historyOp(*m)
+	case AddressSubscribe:
+		key, op = "address:"+strings.ToLower(m.Account.Address), historyWatch
+		if m.EventCode == "unwatch" {
+			op = historyUnwatch
+		}
+	case TxSubscribe:
+		key, op = "tx:"+strings.ToLower(m.Transaction.Hash), historyWatch
+		if m.EventCode == "unwatch" {
+			op = historyUnwatch
+		}
+	case Configuration:
+		key, op = "config:"+strings.ToLower(m.Config.Scope), historyReplace
+		if m.EventCode == "unwatch" {
+			op = historyUnwatch
+		}
+	default:
+		return "", 0, false
+	}
+	return key, op, true
+}
+
+// compact drops watches that were later undone by an unwatch for the same
+// key along with the unwatch itself, unwatches of keys which aren't watched,
+// duplicate watches and configs that were replaced by a later one for the
+// same scope. Any other message is kept.
+func compact(msgs []interface{}) []interface{} {
+	keep := make([]bool, len(msgs))
+	active := make(map[string]int)
+	for i, msg := range msgs {
+		keep[i] = true
+		key, op, ok := historyOp(msg)
+		if !ok {
+			continue
+		}
+		prev, exists := active[key]
+		switch op {
+		case historyWatch:
+			if exists {
+				keep[i] = false
+				continue
+			}
+			active[key] = i
+		case historyReplace:
+			if exists {
+				keep[prev] = false
+			}
+			active[key] = i
+		case historyUnwatch:
+			// unwatches of keys which aren't watched have nothing to undo
+			keep[i] = false
+			if exists {
+				keep[prev] = false
+				delete(active, key)
+			}
+		}
+	}
+	out := make([]interface{}, 0, len(msgs))
+	for i, msg := range msgs {
+		if keep[i] {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
@@ -1,6 +1,10 @@
 package client
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -57,3 +61,96 @@ func TestMsgHistory(t *testing.T) {
 		}
 	}
 }
+
+func TestCompact(t *testing.T) {
+	base := NewBaseMessageMainnet("test")
+	hist := &MsgHistory{}
+	msgs := []interface{}{
+		NewAddressSubscribe(base, "0xaa"),
+		NewAddressSubscribe(base, "0xbb"),
+		NewTxSubscribe(base, "0x01"),
+		NewConfiguration(base, NewConfig("global", false, nil)),
+		NewAddressUnsubscribe(base, "0xAA"),
+		NewAddressSubscribe(base, "0xbb"),
+		NewConfiguration(base, NewConfig("global", true, nil)),
+		NewTxUnsubscribe(base, "0x02"),
+		"unknown",
+	}
+	for _, msg := range msgs {
+		require.NoError(t, hist.Record(msg))
+	}
+	require.NoError(t, hist.Compact())
+	compacted, err := hist.Messages()
+	require.NoError(t, err)
+	// the unwatch of 0x02, which was never watched, is dropped
+	require.Equal(t, []interface{}{msgs[1], msgs[2], msgs[6], msgs[8]}, compacted)
+}
+
+func TestFileHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	base := NewBaseMessageMainnet("test")
+	hist, err := NewFileHistory(path)
+	require.NoError(t, err)
+	require.NoError(t, hist.Record(NewAddressSubscribe(base, "0xaa")))
+	require.NoError(t, hist.Record(NewTxSubscribe(base, "0x01")))
+	require.NoError(t, hist.Record(NewConfiguration(base, NewConfig("global", false, nil))))
+	require.NoError(t, hist.Record(NewTxUnsubscribe(base, "0x01")))
+	require.NoError(t, hist.Close())
+
+	// reopening compacts the file
+	hist, err = NewFileHistory(path)
+	require.NoError(t, err)
+	defer hist.Close()
+	msgs, err := hist.Messages()
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, "0xaa", msgs[0].(AddressSubscribe).Account.Address)
+	require.Equal(t, "global", msgs[1].(Configuration).Config.Scope)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(string(data), "\n"))
+	// the api key is never written to the file
+	require.NotContains(t, string(data), `"dappId":"test"`)
+	require.NoError(t, hist.Record(NewAddressSubscribe(base, "0xbb")))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), `"dappId":"test"`)
+}
+
+func TestHistoryRestore(t *testing.T) {
+	ts := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	hist, err := NewFileHistory(path)
+	require.NoError(t, err)
+	opts := ts.opts()
+	opts.History = hist
+	cl := ts.dial(t, opts)
+	ctx := context.Background()
+	require.NoError(t, cl.WatchAddress(ctx, "0xaa"))
+	require.NoError(t, cl.WatchTx(ctx, "0x01"))
+	require.NoError(t, cl.SetConfig(ctx, NewConfig("global", false, nil)))
+	require.NoError(t, cl.SetConfig(ctx, NewConfig("0xaa", true, nil)))
+	require.NoError(t, cl.RemoveConfig(ctx, "global"))
+	require.NoError(t, cl.UnwatchTx(ctx, "0x01"))
+	require.NoError(t, cl.Close())
+	// closing the client closes its history
+	require.Error(t, hist.Record(NewAddressSubscribe(NewBaseMessageMainnet("test"), "0xbb")))
+	require.NoError(t, hist.Close())
+	want := cl.Subscriptions()
+
+	// the subscriptions can be read without connecting
+	subs, err := HistorySubscriptions(path)
+	require.NoError(t, err)
+	require.Equal(t, want, subs)
+	subs, err = HistorySubscriptions(filepath.Join(t.TempDir(), "missing.ndjson"))
+	require.NoError(t, err)
+	require.Empty(t, subs.Addresses)
+
+	// a new process restores the same subscriptions on initialization
+	hist, err = NewFileHistory(path)
+	require.NoError(t, err)
+	defer hist.Close()
+	opts.History = hist
+	restored := ts.dial(t, opts)
+	require.Equal(t, want, restored.Subscriptions())
+}
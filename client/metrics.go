@@ -0,0 +1,123 @@
+package client
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics bundles the prometheus collectors used to instrument a Client.
+// A nil *Metrics is valid and disables instrumentation.
+type Metrics struct {
+	EventsReceived   *prometheus.CounterVec
+	DecodeErrors     prometheus.Counter
+	Reconnects       prometheus.Counter
+	AckLatency       *prometheus.HistogramVec
+	WatchedAddresses prometheus.Gauge
+	WatchedTxHashes  prometheus.Gauge
+	WatchedConfigs   prometheus.Gauge
+	EventDelay       prometheus.Histogram
+}
+
+// NewMetrics returns a new set of client metrics registered against reg.
+// If reg is nil the collectors are created but not registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		EventsReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blocknative",
+			Name:      "events_received_total",
+			Help:      "Number of transaction events received by event code and status.",
+		}, []string{"event_code", "status"}),
+		DecodeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "blocknative",
+			Name:      "decode_errors_total",
+			Help:      "Number of messages that could not be decoded.",
+		}),
+		Reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "blocknative",
+			Name:      "reconnects_total",
+			Help:      "Number of times the websocket connection was re-established.",
+		}),
+		AckLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "blocknative",
+			Name:      "ack_latency_seconds",
+			Help:      "Time between sending a message and receiving its acknowledgement.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		WatchedAddresses: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "blocknative",
+			Name:      "watched_addresses",
+			Help:      "Number of addresses currently being watched.",
+		}),
+		WatchedTxHashes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "blocknative",
+			Name:      "watched_tx_hashes",
+			Help:      "Number of transaction hashes currently being watched.",
+		}),
+		WatchedConfigs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "blocknative",
+			Name:      "watched_configs",
+			Help:      "Number of configurations currently active.",
+		}),
+		EventDelay: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "blocknative",
+			Name:      "event_delay_seconds",
+			Help:      "Delay between the transaction timestamp and local receipt of the event.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(
+			m.EventsReceived,
+			m.DecodeErrors,
+			m.Reconnects,
+			m.AckLatency,
+			m.WatchedAddresses,
+			m.WatchedTxHashes,
+			m.WatchedConfigs,
+			m.EventDelay,
+		)
+	}
+	return m
+}
+
+func (m *Metrics) observeEvent(msg *EthTxPayload, received time.Time) {
+	if m == nil {
+		return
+	}
+	m.EventsReceived.WithLabelValues(msg.Event.EventCode, msg.Event.Transaction.Status).Inc()
+	if ts := msg.Event.Transaction.TimeStamp; !ts.IsZero() {
+		m.EventDelay.Observe(received.Sub(ts).Seconds())
+	}
+}
+
+func (m *Metrics) observeDecodeError() {
+	if m == nil {
+		return
+	}
+	m.DecodeErrors.Inc()
+}
+
+func (m *Metrics) observeReconnect() {
+	if m == nil {
+		return
+	}
+	m.Reconnects.Inc()
+}
+
+func (m *Metrics) observeAck(method string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.AckLatency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func (m *Metrics) setWatched(subs *subscriptions) {
+	if m == nil {
+		return
+	}
+	addresses, txHashes, configs := subs.counts()
+	m.WatchedAddresses.Set(float64(addresses))
+	m.WatchedTxHashes.Set(float64(txHashes))
+	m.WatchedConfigs.Set(float64(configs))
+}
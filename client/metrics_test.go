@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	ts := newTestServer(t)
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	opts := ts.opts()
+	opts.Metrics = metrics
+	cl := ts.dial(t, opts)
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.AckLatency))
+
+	base := NewBaseMessageMainnet(cl.APIKey())
+	require.NoError(t, cl.WriteJSON(NewAddressSubscribe(base, "0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41")))
+	require.NoError(t, cl.WriteJSON(NewAddressSubscribe(base, "0xFA6DE2697D59E88ED7FC4DFE5A33DAC43565EA41")))
+	require.NoError(t, cl.WriteJSON(NewTxSubscribe(base, "0x01")))
+	require.NoError(t, cl.EventSub(NewConfiguration(base, NewConfig("global", false, nil))))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.WatchedAddresses))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.WatchedTxHashes))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.WatchedConfigs))
+	require.NoError(t, cl.WriteJSON(NewTxUnsubscribe(base, "0x01")))
+	require.Equal(t, float64(0), testutil.ToFloat64(metrics.WatchedTxHashes))
+
+	var ev EthTxPayload
+	ev.Event.EventCode = "txPool"
+	ev.Event.Transaction.Status = "pending"
+	ev.Event.Transaction.TimeStamp = time.Now().Add(-time.Second)
+	ts.events <- ev
+	var out EthTxPayload
+	require.NoError(t, cl.ReadJSON(&out))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.EventsReceived.WithLabelValues("txPool", "pending")))
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.EventDelay))
+
+	ts.events <- "not an event"
+	require.Error(t, cl.ReadJSON(&out))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.DecodeErrors))
+
+	require.NoError(t, cl.Reconnect())
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.Reconnects))
+}
+
+func TestReconnectRestoresSubscriptions(t *testing.T) {
+	ts := newTestServer(t)
+	cl := ts.dial(t, ts.opts())
+	base := NewBaseMessageMainnet(cl.APIKey())
+	require.NoError(t, cl.WriteJSON(NewAddressSubscribe(base, "0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41")))
+	require.NoError(t, cl.EventSub(NewConfiguration(base, NewConfig("global", false, nil))))
+	<-ts.received
+	<-ts.received
+
+	require.NoError(t, cl.Reconnect())
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		msg := <-ts.received
+		seen[msg["categoryCode"].(string)] = true
+	}
+	require.Equal(t, map[string]bool{"initialize": true, "configs": true, "accountAddress": true}, seen)
+}
+
+func TestListenReconnects(t *testing.T) {
+	ts := newTestServer(t)
+	metrics := NewMetrics(prometheus.NewRegistry())
+	opts := ts.opts()
+	opts.Metrics = metrics
+	opts.MaxReconnects = 3
+	opts.ReconnectBackoff = time.Millisecond
+	cl := ts.dial(t, opts)
+	require.NoError(t, cl.WatchAddress(context.Background(), "0xaa"))
+
+	events := make(chan string, 2)
+	listened := make(chan error, 1)
+	go func() {
+		listened <- cl.Listen(context.Background(), func(_ context.Context, msg *EthTxPayload) error {
+			events <- msg.Event.Transaction.Hash
+			return nil
+		})
+	}()
+	var ev EthTxPayload
+	ev.Event.EventCode = "txPool"
+	ev.Event.Transaction.Hash = "0x01"
+	ts.events <- ev
+	require.Equal(t, "0x01", <-events)
+
+	// the dropped connection is re-established along with its subscriptions
+	ts.events <- dropConnection{}
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		msg := <-ts.received
+		seen[msg["categoryCode"].(string)] = true
+	}
+	require.True(t, seen["initialize"])
+	require.True(t, seen["accountAddress"])
+	require.Eventually(t, func() bool { return testutil.ToFloat64(metrics.Reconnects) == 1 }, time.Second, time.Millisecond)
+	ev.Event.Transaction.Hash = "0x02"
+	ts.events <- ev
+	require.Equal(t, "0x02", <-events)
+
+	require.NoError(t, cl.Close())
+	require.Error(t, <-listened)
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Network is a network supported by the api
+type Network struct {
+	System  string
+	Name    string
+	ChainID int64
+}
+
+// Blockchain returns the blockchain params identifying the network in messages
+func (n Network) Blockchain() Blockchain {
+	return Blockchain{System: n.System, Network: n.Name}
+}
+
+// Networks lists the networks supported by the api
+var Networks = []Network{
+	{System: "ethereum", Name: "main", ChainID: 1},
+	{System: "ethereum", Name: "rinkeby", ChainID: 4},
+	{System: "ethereum", Name: "goerli", ChainID: 5},
+	{System: "ethereum", Name: "sepolia", ChainID: 11155111},
+	{System: "ethereum", Name: "matic-main", ChainID: 137},
+	{System: "ethereum", Name: "matic-mumbai", ChainID: 80001},
+	{System: "ethereum", Name: "bsc-main", ChainID: 56},
+	{System: "ethereum", Name: "xdai", ChainID: 100},
+	{System: "ethereum", Name: "fantom-main", ChainID: 250},
+	{System: "ethereum", Name: "optimism-main", ChainID: 10},
+	{System: "ethereum", Name: "arbitrum-main", ChainID: 42161},
+}
+
+// LookupNetwork returns the network of system identified by its name or chain id
+func LookupNetwork(system, network string) (Network, error) {
+	id, err := strconv.ParseInt(network, 10, 64)
+	isID := err == nil
+	for _, n := range Networks {
+		if n.System != system {
+			continue
+		}
+		if (isID && n.ChainID == id) || strings.EqualFold(n.Name, network) {
+			return n, nil
+		}
+	}
+	return Network{}, errors.Errorf("network not supported system:%v network:%v", system, network)
+}
+
+// NetName returns the name of the ethereum network with the chain id
+func NetName(id int64) (string, error) {
+	for _, n := range Networks {
+		if n.System == "ethereum" && n.ChainID == id {
+			return n.Name, nil
+		}
+	}
+	return "", errors.Errorf("network not supported id:%v", id)
+}
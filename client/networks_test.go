@@ -0,0 +1,28 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupNetwork(t *testing.T) {
+	n, err := LookupNetwork("ethereum", "goerli")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n.ChainID)
+
+	n, err = LookupNetwork("ethereum", "137")
+	require.NoError(t, err)
+	require.Equal(t, Blockchain{System: "ethereum", Network: "matic-main"}, n.Blockchain())
+
+	_, err = LookupNetwork("ethereum", "nope")
+	require.Error(t, err)
+	_, err = LookupNetwork("bitcoin", "main")
+	require.Error(t, err)
+
+	name, err := NetName(1)
+	require.NoError(t, err)
+	require.Equal(t, "main", name)
+	_, err = NetName(-1)
+	require.Error(t, err)
+}
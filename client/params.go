@@ -0,0 +1,219 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Params holds the arguments of a decoded contract call. Values are kept as
+// decoded from json, numbers as json.Number so that large integers keep
+// their precision, tuples as Params and arrays as []interface{}.
+type Params map[string]interface{}
+
+// UnmarshalJSON decodes the params preserving the precision of numbers
+func (p *Params) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*p = nil
+		return nil
+	}
+	*p = convertParams(raw)
+	return nil
+}
+
+// convertParams turns nested objects into Params
+func convertParams(raw map[string]interface{}) Params {
+	for k, v := range raw {
+		raw[k] = convertParam(v)
+	}
+	return Params(raw)
+}
+
+func convertParam(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return convertParams(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = convertParam(v[i])
+		}
+		return v
+	}
+	return v
+}
+
+// String returns the named param if it is a string or number
+func (p Params) String(name string) (string, bool) {
+	switch v := p[name].(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// BigInt returns the named param as an integer, it may be a json number or a decimal or hex string
+func (p Params) BigInt(name string) (*big.Int, bool) {
+	return toBigInt(p[name])
+}
+
+// Address returns the named param if it is a hex address
+func (p Params) Address(name string) (common.Address, bool) {
+	s, ok := p[name].(string)
+	if !ok || !common.IsHexAddress(s) {
+		return common.Address{}, false
+	}
+	return common.HexToAddress(s), true
+}
+
+// Bool returns the named param if it is a boolean
+func (p Params) Bool(name string) (bool, bool) {
+	switch v := p[name].(type) {
+	case bool:
+		return v, true
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// Array returns the named param if it is an array
+func (p Params) Array(name string) ([]interface{}, bool) {
+	v, ok := p[name].([]interface{})
+	return v, ok
+}
+
+// Strings returns the named param if it is an array of strings, such as the path of a swap
+func (p Params) Strings(name string) ([]string, bool) {
+	arr, ok := p.Array(name)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, len(arr))
+	for i, v := range arr {
+		switch v := v.(type) {
+		case string:
+			out[i] = v
+		case json.Number:
+			out[i] = v.String()
+		default:
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// Tuple returns the named param if it is a tuple (struct)
+func (p Params) Tuple(name string) (Params, bool) {
+	v, ok := p[name].(Params)
+	return v, ok
+}
+
+// Tuples returns the named param if it is an array of tuples
+func (p Params) Tuples(name string) ([]Params, bool) {
+	arr, ok := p.Array(name)
+	if !ok {
+		return nil, false
+	}
+	out := make([]Params, len(arr))
+	for i, v := range arr {
+		if out[i], ok = v.(Params); !ok {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+func toBigInt(v interface{}) (*big.Int, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return nil, false
+	}
+	return ParseAmount(s)
+}
+
+// UnpackParams decodes abi encoded arguments, such as the input of a call
+// following its selector, into params keyed by argument name
+func UnpackParams(args abi.Arguments, data []byte) (Params, error) {
+	values := make(map[string]interface{})
+	if err := args.UnpackIntoMap(values, data); err != nil {
+		return nil, err
+	}
+	params := make(Params, len(values))
+	for name, v := range values {
+		params[name] = toParam(reflect.ValueOf(v))
+	}
+	return params, nil
+}
+
+// toParam converts a value unpacked by the abi package to the form of params
+// decoded from json: strings for integers, addresses and bytes, Params for tuples
+// and []interface{} for arrays
+func toParam(v reflect.Value) interface{} {
+	switch v := v.Interface().(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	case bool:
+		return v
+	case string:
+		return v
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = toParam(v.Index(i))
+		}
+		return out
+	case reflect.Struct:
+		out := make(Params, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Tag.Get("json")
+			if name == "" {
+				name = v.Type().Field(i).Name
+			}
+			out[name] = toParam(v.Field(i))
+		}
+		return out
+	}
+	return v.Interface()
+}
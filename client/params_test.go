@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParams(t *testing.T) {
+	var tx TransactionPayload
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"hash": "0x01",
+		"contractCall": {
+			"contractType": "Uniswap V2: Router 2",
+			"contractAddress": "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+			"methodName": "swapExactETHForTokens",
+			"params": {
+				"amountOutMin": "123456789012345678901234567890",
+				"deadline": 1700000000,
+				"path": ["0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"],
+				"to": "0x1111111111111111111111111111111111111111",
+				"order": {"maker": "0x2222222222222222222222222222222222222222", "amount": "0x10"},
+				"calls": [{"target": "0x3333333333333333333333333333333333333333", "allowFailure": false}],
+				"unlimited": "true"
+			}
+		}
+	}`), &tx))
+
+	call := tx.ContractCall
+	require.NotNil(t, call)
+	require.Equal(t, "swapExactETHForTokens", call.MethodName)
+	p := call.Params
+
+	amount, ok := p.BigInt("amountOutMin")
+	require.True(t, ok)
+	require.Equal(t, "123456789012345678901234567890", amount.String())
+	deadline, ok := p.BigInt("deadline")
+	require.True(t, ok)
+	require.Equal(t, int64(1700000000), deadline.Int64())
+	s, ok := p.String("deadline")
+	require.True(t, ok)
+	require.Equal(t, "1700000000", s)
+
+	path, ok := p.Strings("path")
+	require.True(t, ok)
+	require.Len(t, path, 2)
+	to, ok := p.Address("to")
+	require.True(t, ok)
+	require.Equal(t, common.HexToAddress("0x1111111111111111111111111111111111111111"), to)
+
+	order, ok := p.Tuple("order")
+	require.True(t, ok)
+	orderAmount, ok := order.BigInt("amount")
+	require.True(t, ok)
+	require.Equal(t, int64(16), orderAmount.Int64())
+
+	calls, ok := p.Tuples("calls")
+	require.True(t, ok)
+	allowFailure, ok := calls[0].Bool("allowFailure")
+	require.True(t, ok)
+	require.False(t, allowFailure)
+	unlimited, ok := p.Bool("unlimited")
+	require.True(t, ok)
+	require.True(t, unlimited)
+
+	_, ok = p.Address("amountOutMin")
+	require.False(t, ok)
+	_, ok = p.Tuple("path")
+	require.False(t, ok)
+	_, ok = p.BigInt("missing")
+	require.False(t, ok)
+
+	// transactions without a decoded call have none
+	var plain TransactionPayload
+	require.NoError(t, json.Unmarshal([]byte(`{"hash": "0x02"}`), &plain))
+	require.Nil(t, plain.ContractCall)
+}
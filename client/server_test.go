@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// dropConnection makes the test server close the connection when sent on events
+type dropConnection struct{}
+
+// rejectedScope is a config scope and address the test server refuses
+const rejectedScope = "rejected"
+
+// testServer is a minimal stand-in for the blocknative websockets api.
+// It acknowledges initialize and subscription messages, records every message
+// it receives and forwards anything sent on events to the connected client
+type testServer struct {
+	*httptest.Server
+	received chan map[string]interface{}
+	events   chan interface{}
+	connects int
+	mx       sync.Mutex
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ts := &testServer{
+		received: make(chan map[string]interface{}, 100),
+		events:   make(chan interface{}, 100),
+	}
+	upgrader := websocket.Upgrader{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ts.mx.Lock()
+		ts.connects++
+		ts.mx.Unlock()
+		var wmx sync.Mutex
+		write := func(v interface{}) error {
+			wmx.Lock()
+			defer wmx.Unlock()
+			return conn.WriteJSON(v)
+		}
+		if err := write(ConnectResponse{Status: "ok", ConnectionID: "test", ServerVersion: "0.0.0"}); err != nil {
+			return
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case ev := <-ts.events:
+					if _, ok := ev.(dropConnection); ok {
+						conn.Close()
+						return
+					}
+					if err := write(ev); err != nil {
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+		for {
+			var msg map[string]interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			ts.received <- msg
+			switch msg["categoryCode"] {
+			case "initialize", "configs", "accountAddress", "activeTransaction":
+				ack := ConnectResponse{Status: "ok"}
+				if cfg, ok := msg["config"].(map[string]interface{}); ok && cfg["scope"] == rejectedScope {
+					ack = ConnectResponse{Status: "error", Reason: "invalid config"}
+				}
+				if account, ok := msg["account"].(map[string]interface{}); ok && account["address"] == rejectedScope {
+					ack = ConnectResponse{Status: "error", Reason: "invalid address"}
+				}
+				if err := write(ack); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// opts returns client options pointing at the test server
+func (ts *testServer) opts() Opts {
+	return Opts{Scheme: "ws", Host: strings.TrimPrefix(ts.URL, "http://"), APIKey: "test"}
+}
+
+// dial returns an initialized client connected to the test server
+func (ts *testServer) dial(t *testing.T, opts Opts) *Client {
+	cl, err := New(context.Background(), opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { cl.Close() })
+	require.NoError(t, cl.Initialize(NewBaseMessageMainnet(opts.APIKey)))
+	// skip anything left over from previous connections
+	for msg := range ts.received {
+		if msg["categoryCode"] == "initialize" {
+			break
+		}
+	}
+	return cl
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"time"
+)
+
+// EventCodeSimulation is the event code of simulation results for pending transactions
+const EventCodeSimulation = "txPoolSimulation"
+
+// SimDetails describes the simulation of a pending transaction
+type SimDetails struct {
+	// BlockNumber is the block the transaction was simulated against
+	BlockNumber uint64 `json:"blockNumber"`
+	// E2EMs is the time in milliseconds from detecting the transaction to publishing the simulation
+	E2EMs              int64              `json:"e2eMs"`
+	PerformanceProfile PerformanceProfile `json:"performanceProfile"`
+}
+
+// PerformanceProfile breaks down the time taken by a simulation
+type PerformanceProfile struct {
+	Breakdown []PerformanceStep `json:"breakdown"`
+}
+
+// PerformanceStep is a step of a simulation and when it completed
+type PerformanceStep struct {
+	Label     string    `json:"label"`
+	TimeStamp time.Time `json:"timeStamp"`
+}
+
+// IsSimulation reports whether the payload carries simulation results
+func (p *EthTxPayload) IsSimulation() bool {
+	return p.Event.EventCode == EventCodeSimulation
+}
+
+// Failed reports whether the simulated transaction or any of its internal transactions failed
+func (t TransactionPayload) Failed() bool {
+	if t.SimError != "" {
+		return true
+	}
+	for _, itx := range t.InternalTransactions {
+		if itx.ErrorReason != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const simulationPayload = `{
+	"version": 1,
+	"status": "ok",
+	"event": {
+		"categoryCode": "activeAddress",
+		"eventCode": "txPoolSimulation",
+		"blockchain": {"system": "ethereum", "network": "main"},
+		"transaction": {
+			"status": "pending",
+			"hash": "0x01",
+			"from": "0xaa",
+			"to": "0xrouter",
+			"gasUsed": 120000,
+			"simError": "",
+			"simDetails": {
+				"blockNumber": 17000000,
+				"e2eMs": 195,
+				"performanceProfile": {"breakdown": [{"label": "detected", "timeStamp": "2023-04-01T00:00:00.1Z"}]}
+			},
+			"internalTransactions": [{
+				"type": "CALL", "from": "0xrouter", "to": "0xusdc", "gas": 50000, "gasUsed": 30000, "value": "0",
+				"contractCall": {"contractAddress": "0xUSDC", "contractDecimals": 6, "methodName": "transfer"}
+			}],
+			"netBalanceChanges": [{
+				"address": "0xaa",
+				"balanceChanges": [
+					{"delta": "-1500000000000000000", "asset": {"type": "ether", "symbol": "ETH"}},
+					{"delta": "2500000000", "asset": {"type": "erc20", "symbol": "USDC", "contractAddress": "0xusdc"}},
+					{"delta": "7", "asset": {"type": "erc20", "symbol": "XYZ", "contractAddress": "0xxyz"}}
+				]
+			}]
+		}
+	}
+}`
+
+func TestSimulation(t *testing.T) {
+	var msg EthTxPayload
+	require.NoError(t, json.Unmarshal([]byte(simulationPayload), &msg))
+	require.True(t, msg.IsSimulation())
+	tx := msg.Event.Transaction
+	require.False(t, tx.Failed())
+	require.Equal(t, uint64(17000000), tx.SimDetails.BlockNumber)
+	require.Equal(t, int64(195), tx.SimDetails.E2EMs)
+	require.Equal(t, "detected", tx.SimDetails.PerformanceProfile.Breakdown[0].Label)
+	require.Equal(t, float64(120000), tx.GasUsed)
+
+	deltas := AssetDeltas(tx, nil)
+	require.Len(t, deltas, 3)
+	amounts := make([]string, len(deltas))
+	for i, d := range deltas {
+		amounts[i] = d.Amount().Text('f', 2) + " " + d.Asset.Symbol
+	}
+	// the xyz decimals are unknown so it stays in its smallest unit
+	require.Equal(t, []string{"-1.50 ETH", "2500.00 USDC", "7.00 XYZ"}, amounts)
+	require.Equal(t, -1, deltas[2].Decimals)
+
+	deltas = AssetDeltas(tx, map[string]int{"0xXYZ": 1})
+	require.Equal(t, "0.7", deltas[2].Amount().Text('f', 1))
+
+	tx.InternalTransactions[0].ErrorReason = "execution reverted"
+	require.True(t, tx.Failed())
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Subscriptions is a snapshot of the watches active on a connection
+type Subscriptions struct {
+	Addresses []string `json:"addresses"`
+	TxHashes  []string `json:"txHashes"`
+	Configs   []Config `json:"configs"`
+}
+
+// subscriptions keeps track of the watches that are currently active on a
+// connection so that they can be reported on and re-established
+type subscriptions struct {
+	mx        sync.RWMutex
+	addresses map[string]struct{}
+	txHashes  map[string]struct{}
+	configs   map[string]Config
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		addresses: make(map[string]struct{}),
+		txHashes:  make(map[string]struct{}),
+		configs:   make(map[string]Config),
+	}
+}
+
+// track updates the active state based on a message sent to the api,
+// returning true if msg is a subscription message
+func (s *subscriptions) track(msg interface{}) bool {
+	switch m := msg.(type) {
+	case *AddressSubscribe:
+		msg = *m
+	case *TxSubscribe:
+		msg = *m
+	case *Configuration:
+		msg = *m
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	switch m := msg.(type) {
+	case AddressSubscribe:
+		key := strings.ToLower(m.Account.Address)
+		switch m.EventCode {
+		case "watch":
+			s.addresses[key] = struct{}{}
+		case "unwatch":
+			delete(s.addresses, key)
+		}
+	case TxSubscribe:
+		key := strings.ToLower(m.Transaction.Hash)
+		switch m.EventCode {
+		case "txSent":
+			s.txHashes[key] = struct{}{}
+		case "unwatch":
+			delete(s.txHashes, key)
+		}
+	case Configuration:
+		key := strings.ToLower(m.Config.Scope)
+		switch m.EventCode {
+		case "unwatch":
+			delete(s.configs, key)
+		default:
+			s.configs[key] = m.Config
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// counts returns the number of active addresses, tx hashes and configs
+func (s *subscriptions) counts() (addresses, txHashes, configs int) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return len(s.addresses), len(s.txHashes), len(s.configs)
+}
+
+// snapshot returns a sorted copy of the active state
+func (s *subscriptions) snapshot() Subscriptions {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	out := Subscriptions{
+		Addresses: make([]string, 0, len(s.addresses)),
+		TxHashes:  make([]string, 0, len(s.txHashes)),
+		Configs:   make([]Config, 0, len(s.configs)),
+	}
+	for addr := range s.addresses {
+		out.Addresses = append(out.Addresses, addr)
+	}
+	for hash := range s.txHashes {
+		out.TxHashes = append(out.TxHashes, hash)
+	}
+	for _, cfg := range s.configs {
+		out.Configs = append(out.Configs, cfg)
+	}
+	sort.Strings(out.Addresses)
+	sort.Strings(out.TxHashes)
+	sort.Slice(out.Configs, func(i, j int) bool { return out.Configs[i].Scope < out.Configs[j].Scope })
+	return out
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionsAndStatus(t *testing.T) {
+	ts := newTestServer(t)
+	cl := ts.dial(t, ts.opts())
+	ctx := context.Background()
+
+	status := cl.Status()
+	require.True(t, status.Connected)
+	require.True(t, status.Initialized)
+	require.Equal(t, "test", status.ConnectionID)
+	require.Equal(t, "0.0.0", status.ServerVersion)
+	require.Equal(t, "main", status.Network)
+
+	require.NoError(t, cl.WatchAddress(ctx, "0xBB"))
+	require.NoError(t, cl.WatchAddress(ctx, "0xaa"))
+	require.NoError(t, cl.WatchTx(ctx, "0x01"))
+	require.NoError(t, cl.SetConfig(ctx, NewConfig("0xaa", true, nil)))
+	require.NoError(t, cl.SetConfig(ctx, NewConfig("global", false, nil)))
+	require.NoError(t, cl.UnwatchTx(ctx, "0x01"))
+	require.NoError(t, cl.RemoveConfig(ctx, "global"))
+
+	subs := cl.Subscriptions()
+	require.Equal(t, []string{"0xaa", "0xbb"}, subs.Addresses)
+	require.Empty(t, subs.TxHashes)
+	require.Equal(t, []Config{NewConfig("0xaa", true, nil)}, subs.Configs)
+
+	require.NoError(t, cl.Close())
+	require.False(t, cl.Status().Connected)
+}
+
+func TestSetConfigAck(t *testing.T) {
+	ts := newTestServer(t)
+	cl := ts.dial(t, ts.opts())
+	ctx := context.Background()
+
+	require.ErrorContains(t, cl.SetConfig(ctx, NewConfig(rejectedScope, false, nil)), "invalid config")
+
+	// while listening the acknowledgements are read by Listen
+	listened := make(chan error, 1)
+	go func() {
+		listened <- cl.Listen(ctx, func(context.Context, *EthTxPayload) error { return nil })
+	}()
+	require.Eventually(t, cl.listening.Load, time.Second, time.Millisecond)
+	require.NoError(t, cl.SetConfig(ctx, NewConfig("global", false, nil)))
+	require.ErrorContains(t, cl.SetConfig(ctx, NewConfig(rejectedScope, false, nil)), "invalid config")
+	require.ErrorContains(t, cl.RemoveConfig(ctx, rejectedScope), "invalid config")
+	require.Equal(t, []Config{NewConfig("global", false, nil)}, cl.Subscriptions().Configs)
+
+	require.NoError(t, cl.Close())
+	require.Error(t, <-listened)
+}
+
+func TestSetConfigAckRace(t *testing.T) {
+	ts := newTestServer(t)
+	cl := ts.dial(t, ts.opts())
+	ctx := context.Background()
+	listened := make(chan error, 1)
+	go func() {
+		listened <- cl.Listen(ctx, func(context.Context, *EthTxPayload) error { return nil })
+	}()
+	require.Eventually(t, cl.listening.Load, time.Second, time.Millisecond)
+
+	// the acknowledgements of watches sent concurrently are never taken for
+	// those of configs, nor the other way around
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, cl.WatchAddress(ctx, "0xaa"))
+			require.ErrorContains(t, cl.WatchAddress(ctx, rejectedScope), "invalid address")
+		}()
+		go func() {
+			defer wg.Done()
+			require.ErrorContains(t, cl.SetConfig(ctx, NewConfig(rejectedScope, false, nil)), "invalid config")
+			require.NoError(t, cl.SetConfig(ctx, NewConfig("global", false, nil)))
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, cl.Close())
+	require.Error(t, <-listened)
+}
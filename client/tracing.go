@@ -0,0 +1,71 @@
+package client
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/tiennampham23/go-blocknative/client"
+
+// span attribute keys
+const (
+	AttrCategoryCode   = attribute.Key("blocknative.category_code")
+	AttrEventCode      = attribute.Key("blocknative.event_code")
+	AttrSystem         = attribute.Key("blocknative.system")
+	AttrNetwork        = attribute.Key("blocknative.network")
+	AttrTxHash         = attribute.Key("blocknative.tx.hash")
+	AttrTxStatus       = attribute.Key("blocknative.tx.status")
+	AttrWatchedAddress = attribute.Key("blocknative.watched_address")
+	AttrConfigScope    = attribute.Key("blocknative.config.scope")
+)
+
+func baseAttributes(msg BaseMessage) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrCategoryCode.String(msg.CategoryCode),
+		AttrEventCode.String(msg.EventCode),
+		AttrSystem.String(msg.System),
+		AttrNetwork.String(msg.Network),
+	}
+}
+
+// messageSpan returns the span name and attributes used when sending msg,
+// ok is false if msg is not a subscription message
+func messageSpan(msg interface{}) (name string, attrs []attribute.KeyValue, ok bool) {
+	switch m := msg.(type) {
+	case *AddressSubscribe:
+		return messageSpan(*m)
+	case *TxSubscribe:
+		return messageSpan(*m)
+	case *Configuration:
+		return messageSpan(*m)
+	case AddressSubscribe:
+		attrs = append(baseAttributes(m.BaseMessage), AttrWatchedAddress.String(m.Account.Address))
+	case TxSubscribe:
+		attrs = append(baseAttributes(m.BaseMessage), AttrTxHash.String(m.Transaction.Hash))
+	case Configuration:
+		attrs = append(baseAttributes(m.BaseMessage), AttrConfigScope.String(m.Config.Scope))
+	default:
+		return "", nil, false
+	}
+	return "blocknative." + attrs[0].Value.AsString() + "." + attrs[1].Value.AsString(), attrs, true
+}
+
+// eventAttributes returns the attributes describing a received event
+func eventAttributes(msg *EthTxPayload) []attribute.KeyValue {
+	return append(
+		baseAttributes(msg.Event.BaseMessage),
+		AttrTxHash.String(msg.Event.Transaction.Hash),
+		AttrTxStatus.String(msg.Event.Transaction.Status),
+		AttrWatchedAddress.String(msg.Event.Transaction.WatchedAddress),
+	)
+}
+
+// endSpan records err on span if set and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
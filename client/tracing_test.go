@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	ts := newTestServer(t)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	opts := ts.opts()
+	opts.TracerProvider = provider
+	cl := ts.dial(t, opts)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "pipeline")
+	require.NoError(t, cl.WatchAddress(ctx, "0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41"))
+	require.NoError(t, cl.EventSubContext(ctx, NewConfiguration(NewBaseMessageMainnet(cl.APIKey()), NewConfig("global", false, nil))))
+
+	var ev EthTxPayload
+	ev.Event.EventCode = "txPool"
+	ev.Event.Network = "main"
+	ev.Event.Transaction.Hash = "0x01"
+	ev.Event.Transaction.Status = "pending"
+	ts.events <- ev
+
+	var handled trace.SpanContext
+	err := cl.Listen(ctx, func(ctx context.Context, msg *EthTxPayload) error {
+		handled = trace.SpanContextFromContext(ctx)
+		return context.Canceled
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	parent.End()
+
+	spans := exporter.GetSpans().Snapshots()
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans {
+		byName[span.Name()] = span
+	}
+	for _, name := range []string{
+		"blocknative.initialize",
+		"blocknative.accountAddress.watch",
+		"blocknative.event_sub",
+		"blocknative.event",
+	} {
+		require.Contains(t, byName, name)
+	}
+
+	watch := byName["blocknative.accountAddress.watch"]
+	require.Equal(t, parent.SpanContext().SpanID(), watch.Parent().SpanID())
+	require.Contains(t, watch.Attributes(), AttrWatchedAddress.String("0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41"))
+
+	require.Equal(t, parent.SpanContext().SpanID(), byName["blocknative.event_sub"].Parent().SpanID())
+
+	event := byName["blocknative.event"]
+	require.Equal(t, event.SpanContext().SpanID(), handled.SpanID())
+	require.Equal(t, parent.SpanContext().SpanID(), event.Parent().SpanID())
+	attrs := attribute.NewSet(event.Attributes()...)
+	hash, _ := attrs.Value(AttrTxHash)
+	require.Equal(t, "0x01", hash.AsString())
+	network, _ := attrs.Value(AttrNetwork)
+	require.Equal(t, "main", network.AsString())
+	code, _ := attrs.Value(AttrEventCode)
+	require.Equal(t, "txPool", code.AsString())
+}
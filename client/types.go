@@ -1,8 +1,12 @@
 package client
 
 import (
+	"math/big"
 	"os"
 	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
 )
 
 // BaseMessage is the base message required for all interactions with the websockets api
@@ -43,15 +47,13 @@ type Account struct {
 	Address string `json:"address"`
 }
 
+// ContractCall is a contract method call decoded by the api
 type ContractCall struct {
 	ContractType    string `json:"contractType"`
 	ContractAddress string `json:"contractAddress"`
 	MethodName      string `json:"methodName"`
-	Params          struct {
-		From  string `json:"_from"`
-		To    string `json:"_to"`
-		Value string `json:"_value"`
-	} `json:"params"`
+	// Params holds the decoded arguments of the call keyed by parameter name
+	Params           Params `json:"params"`
 	ContractAlias    string `json:"contractAlias"`
 	ContractDecimals int    `json:"contractDecimals"`
 	ContractName     string `json:"contractName"`
@@ -67,22 +69,42 @@ type InternalTransaction struct {
 	GasUsed      int          `json:"gasUsed"`
 	Value        string       `json:"value"`
 	ContractCall ContractCall `json:"contractCall"`
+	// ErrorReason is set when the call reverted during simulation
+	ErrorReason string `json:"errorReason,omitempty"`
+	// Depth is the depth of the call below the transaction starting at 1,
+	// and TraceAddress the indexes of the calls leading to it. They are only
+	// set by traces which report them, see the calltree package.
+	Depth        int   `json:"depth,omitempty"`
+	TraceAddress []int `json:"traceAddress,omitempty"`
 }
 
+// NetBalanceChange holds the balance changes of an address caused by a transaction
 type NetBalanceChange struct {
-	Address        string `json:"address"`
-	BalanceChanges []struct {
-		Delta string `json:"delta"`
-		Asset struct {
-			Type            string `json:"type"`
-			Symbol          string `json:"symbol"`
-			ContractAddress string `json:"contractAddress"`
-		} `json:"asset"`
-		Breakdown []struct {
-			Counterparty string `json:"counterparty"`
-			Amount       string `json:"amount"`
-		} `json:"breakdown"`
-	} `json:"balanceChanges"`
+	Address        string          `json:"address"`
+	BalanceChanges []BalanceChange `json:"balanceChanges"`
+}
+
+// BalanceChange is the net change of the balance of an asset, Delta is in
+// the smallest unit of the asset and is negative for decreases
+type BalanceChange struct {
+	Delta     *big.Int    `json:"delta"`
+	Asset     Asset       `json:"asset"`
+	Breakdown []Breakdown `json:"breakdown"`
+}
+
+// Asset identifies an asset, Type is "ether" for the native asset
+type Asset struct {
+	Type            string `json:"type"`
+	Symbol          string `json:"symbol"`
+	ContractAddress string `json:"contractAddress"`
+	// Decimals is not sent by the api, it may be set from other metadata such as ContractCall.ContractDecimals
+	Decimals int `json:"decimals,omitempty"`
+}
+
+// Breakdown is the amount of a balance change transferred with a counterparty
+type Breakdown struct {
+	Counterparty string   `json:"counterparty"`
+	Amount       *big.Int `json:"amount"`
 }
 
 type TransactionPayload struct {
@@ -114,9 +136,15 @@ type TransactionPayload struct {
 	WatchedAddress       string    `json:"watchedAddress"`
 	Direction            string    `json:"direction"`
 	Counterparty         string    `json:"counterparty"`
+	// ContractCall is set when the api decoded the call made by the transaction
+	ContractCall *ContractCall `json:"contractCall,omitempty"`
 	// Internal Transactions Payload
 	InternalTransactions []InternalTransaction `json:"internalTransactions"`
 	NetBalanceChanges    []NetBalanceChange    `json:"netBalanceChanges"`
+	// Simulation payload, set on txPoolSimulation events. GasUsed is the gas used by the simulation.
+	SimDetails *SimDetails `json:"simDetails,omitempty"`
+	// SimError is the reason the simulated transaction failed
+	SimError string `json:"simError,omitempty"`
 }
 
 // EthTxPayload is payload returned from a subscription to blocknative api
@@ -126,7 +154,9 @@ type EthTxPayload struct {
 	TimeStamp     time.Time `json:"timeStamp"`
 	ConnectionID  string    `json:"connectionId"`
 	Status        string    `json:"status"`
-	Event         struct {
+	// Reason explains a status other than ok in acknowledgements
+	Reason string `json:"reason,omitempty"`
+	Event  struct {
 		BaseMessage
 		Transaction TransactionPayload `json:"transaction"`
 	} `json:"event"`
@@ -149,6 +179,8 @@ type Config struct {
 	ABI interface{} `json:"abi,omitempty"`
 	// defines whether the service should automatically watch the address as defined in
 	WatchAddress bool `json:"watchAddress,omitempty"`
+	// Simulate opts in to txPoolSimulation events for pending transactions in scope
+	Simulate bool `json:"simulate,omitempty"`
 }
 
 // NewConfig returns a new config instance
@@ -164,6 +196,22 @@ func NewConfig(scope string, watchAddress bool, abis interface{}) Config {
 	return cfg
 }
 
+// Validate checks that the config can be accepted by the api
+func (c Config) Validate() error {
+	if c.Scope != "global" && !common.IsHexAddress(c.Scope) {
+		return errors.Errorf("scope must be 'global' or an address:%v", c.Scope)
+	}
+	if c.WatchAddress && c.Scope == "global" {
+		return errors.New("watchAddress requires an address scope")
+	}
+	for i, filter := range c.Filters {
+		if len(filter) == 0 {
+			return errors.Errorf("filter %d is empty", i)
+		}
+	}
+	return nil
+}
+
 // NewConfiguration constructs a new configuration message
 func NewConfiguration(msg BaseMessage, config Config) Configuration {
 	msg.CategoryCode = "configs"
@@ -214,17 +262,22 @@ func NewAddressUnsubscribe(msg BaseMessage, address string) AddressSubscribe {
 	}
 }
 
-// NewBaseMessageMainnet returns a base message suitable for mainnet usage
-func NewBaseMessageMainnet(apiKey string) BaseMessage {
+// NewBaseMessage returns a base message for the network identified by chain
+func NewBaseMessage(apiKey string, chain Blockchain) BaseMessage {
 	if apiKey == "" {
 		apiKey = os.Getenv("BLOCKNATIVE_DAPP_ID")
 	}
 	return BaseMessage{
-		Timestamp: time.Now(),
-		DappID:    apiKey,
-		Blockchain: Blockchain{
-			System:  "ethereum",
-			Network: "main",
-		},
+		Timestamp:  time.Now(),
+		DappID:     apiKey,
+		Blockchain: chain,
 	}
 }
+
+// NewBaseMessageMainnet returns a base message suitable for mainnet usage
+func NewBaseMessageMainnet(apiKey string) BaseMessage {
+	return NewBaseMessage(apiKey, Blockchain{
+		System:  "ethereum",
+		Network: "main",
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/admin"
+	"github.com/urfave/cli/v2"
+)
+
+// serveAdmin serves the admin api in the background, changes are
+// persisted by the client's history when enabled. The api may only be
+// served on loopback addresses unless a token is required.
+func serveAdmin(c *cli.Context) error {
+	addr, token := c.String("admin.addr"), c.String("admin.token")
+	if token == "" && !isLoopback(addr) {
+		return errors.Errorf("admin api requires --admin.token when not served on a loopback address addr:%v", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "serving admin api")
+	}
+	srv := admin.New(apiClient, token)
+	go func() {
+		if err := http.Serve(ln, srv.Handler()); err != nil {
+			log.Println("admin server exited: ", err)
+		}
+	}()
+	return nil
+}
+
+// isLoopback reports whether addr only listens on the loopback interface
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log"
+
+	"github.com/tiennampham23/go-blocknative/client"
+	"github.com/tiennampham23/go-blocknative/configfile"
+	"github.com/urfave/cli/v2"
+)
+
+var configCommand = &cli.Command{
+	Name:  "config",
+	Usage: "manage the configurations applied to the connection",
+	Subcommands: cli.Commands{
+		&cli.Command{
+			Name:  "apply",
+			Usage: "validate and apply the configurations declared in a yaml or json file",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "file",
+					Aliases:  []string{"f"},
+					Usage:    "yaml or json file declaring the configurations",
+					Required: true,
+				},
+				&cli.BoolFlag{
+					Name:  "stream",
+					Usage: "stay connected and write events after applying the configurations",
+				},
+			}, outputFlags()...),
+			Action: func(c *cli.Context) error {
+				// validate before connecting so that mistakes are reported without touching the api
+				configs, err := configfile.Load(c.String("file"))
+				if err != nil {
+					return err
+				}
+				if err := loadRegistry(c); err != nil {
+					return err
+				}
+				registry.FillABIs(configs)
+				if err := connect(c); err != nil {
+					return err
+				}
+				base := client.NewBaseMessage(c.String("api.key"), network.Blockchain())
+				for _, cfg := range configs {
+					if err := apiClient.EventSubContext(c.Context, client.NewConfiguration(base, cfg)); err != nil {
+						apiClient.Close()
+						return err
+					}
+					log.Printf("applied config scope:%v", cfg.Scope)
+				}
+				if !c.Bool("stream") {
+					return apiClient.Close()
+				}
+				w, err := newOutputWriter(c)
+				if err != nil {
+					apiClient.Close()
+					return err
+				}
+				defer w.Close()
+				return listen(c, w.Write)
+			},
+		},
+	},
+}
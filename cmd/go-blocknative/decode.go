@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+	"github.com/tiennampham23/go-blocknative/fourbyte"
+	"github.com/urfave/cli/v2"
+)
+
+var decodeCommand = &cli.Command{
+	Name:      "decode",
+	Usage:     "decode calldata with the abi of the contract called, the selector database or by guessing argument types",
+	ArgsUsage: "<calldata|->",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "address of the contract called, used to find its abi in the abi-dir registry",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		input := c.Args().First()
+		if input == "" {
+			return errors.New("calldata argument is required")
+		}
+		if input == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			input = string(data)
+		}
+		data, err := hexutil.Decode(strings.TrimSpace(input))
+		if err != nil {
+			return errors.Wrap(err, "invalid calldata")
+		}
+		if err := loadRegistry(c); err != nil {
+			return err
+		}
+		if err := loadSelectors(c); err != nil {
+			return err
+		}
+		call, err := decodeCalldata(c.String("to"), data)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(call)
+	},
+}
+
+// decodeCalldata decodes data with the abi registered for to, falling back
+// to the selector database and then to guessing the argument types
+func decodeCalldata(to string, data []byte) (*fourbyte.Call, error) {
+	if len(data) < 4 {
+		return nil, errors.Errorf("invalid calldata length:%v", len(data))
+	}
+	if contract, ok := registry.Lookup(to); ok {
+		if method, err := contract.ABI.MethodById(data[:4]); err == nil {
+			params, err := client.UnpackParams(method.Inputs, data[4:])
+			if err != nil {
+				return nil, errors.Wrapf(err, "decoding input method:%v", method.RawName)
+			}
+			return &fourbyte.Call{Selector: hexutil.Encode(data[:4]), Signature: method.Sig, Method: method.RawName, Params: params}, nil
+		}
+	}
+	return selectors.DecodeOrGuess(data)
+}
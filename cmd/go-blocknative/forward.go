@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/tiennampham23/go-blocknative/sink"
+	"github.com/tiennampham23/go-blocknative/store"
+	"github.com/urfave/cli/v2"
+)
+
+var forwardCommand = &cli.Command{
+	Name:   "forward",
+	Usage:  "forward subscribed events to external destinations",
+	Before: connect,
+	Subcommands: cli.Commands{
+		&cli.Command{
+			Name:  "webhook",
+			Usage: "post events as json to one or more urls",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:     "url",
+					Usage:    "url to post events to, may be repeated",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    "secret",
+					EnvVars: []string{"BLOCKNATIVE_WEBHOOK_SECRET"},
+					Usage:   "secret used to sign request bodies, signing is disabled if empty",
+				},
+				&cli.IntFlag{
+					Name:  "retries",
+					Usage: "number of times a failed delivery is retried",
+					Value: 3,
+				},
+				&cli.DurationFlag{
+					Name:  "backoff",
+					Usage: "delay before the first retry, doubled on every attempt",
+					Value: sink.DefaultWebhookBackoff,
+				},
+				&cli.IntFlag{
+					Name:  "concurrency",
+					Usage: "maximum number of events being delivered at once",
+					Value: 4,
+				},
+				&cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "timeout of a single delivery attempt",
+					Value: sink.DefaultWebhookTimeout,
+				},
+				&cli.StringFlag{
+					Name:  "dead-letter",
+					Usage: "file that failed deliveries are appended to",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				hook, err := sink.NewWebhook(sink.WebhookOpts{
+					URLs:           c.StringSlice("url"),
+					Secret:         c.String("secret"),
+					MaxRetries:     c.Int("retries"),
+					Backoff:        c.Duration("backoff"),
+					Concurrency:    c.Int("concurrency"),
+					Timeout:        c.Duration("timeout"),
+					DeadLetterPath: c.String("dead-letter"),
+				})
+				if err != nil {
+					return err
+				}
+				defer hook.Close()
+				return listen(c, hook.Write)
+			},
+		},
+		&cli.Command{
+			Name:  "sqlite",
+			Usage: "archive events into a sqlite database",
+			Flags: []cli.Flag{dbFlag()},
+			Action: func(c *cli.Context) error {
+				db, err := store.OpenSQLite(c.String("db"))
+				if err != nil {
+					return err
+				}
+				defer db.Close()
+				return listen(c, db.Write)
+			},
+		},
+	},
+}
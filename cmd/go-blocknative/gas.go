@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tiennampham23/go-blocknative/gasstats"
+	"github.com/urfave/cli/v2"
+)
+
+var gasCommand = &cli.Command{
+	Name:  "gas",
+	Usage: "gas price commands",
+	Subcommands: cli.Commands{
+		&cli.Command{
+			Name:   "estimate",
+			Usage:  "estimate the fees needed for inclusion from the fees paid by confirmed transactions",
+			Before: connect,
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "blocks",
+					Usage: "number of blocks the transaction should be included within",
+					Value: 1,
+				},
+				&cli.Float64SliceFlag{
+					Name:  "confidence",
+					Usage: "percentage of transactions paying the estimated fee that were included in time",
+					Value: cli.NewFloat64Slice(70, 80, 90, 95, 99),
+				},
+				&cli.DurationFlag{
+					Name:  "window",
+					Usage: "period of confirmations estimates are based on",
+					Value: 30 * time.Minute,
+				},
+				&cli.DurationFlag{
+					Name:  "interval",
+					Usage: "how often estimates are printed",
+					Value: 30 * time.Second,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				est := gasstats.NewGasEstimator(gasstats.EstimatorOpts{
+					Opts: gasstats.Opts{Window: c.Duration("window")},
+				})
+				ctx, cancel := context.WithCancel(c.Context)
+				defer cancel()
+				go func() {
+					ticker := time.NewTicker(c.Duration("interval"))
+					defer ticker.Stop()
+					for {
+						select {
+						case <-ticker.C:
+							printEstimates(est, c.Float64Slice("confidence"), c.Int("blocks"))
+						case <-ctx.Done():
+							return
+						}
+					}
+				}()
+				return listen(c, est.Write)
+			},
+		},
+	},
+}
+
+// printEstimates prints the estimate for every confidence as a table
+func printEstimates(est *gasstats.GasEstimator, confidences []float64, blocks int) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintln(w, "CONFIDENCE\tBLOCKS\tPRIORITY FEE\tMAX FEE\tBASE FEE\tSAMPLES")
+	for _, confidence := range confidences {
+		e, err := est.Estimate(confidence, blocks)
+		if err != nil {
+			fmt.Fprintf(w, "%v%%\t%d\t%v\n", confidence, blocks, err)
+			continue
+		}
+		fmt.Fprintf(w, "%v%%\t%d\t%.2f\t%.2f\t%.2f\t%d\n", e.Confidence, e.Blocks, e.PriorityFee, e.MaxFee, e.BaseFee, e.Samples)
+	}
+	w.Flush()
+}
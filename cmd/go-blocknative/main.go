@@ -1,50 +1,68 @@
 package main
 
 import (
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
-	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tiennampham23/go-blocknative/abiregistry"
 	"github.com/tiennampham23/go-blocknative/client"
+	"github.com/tiennampham23/go-blocknative/fourbyte"
+	"github.com/tiennampham23/go-blocknative/watchlist"
 	"github.com/urfave/cli/v2"
 )
 
 var (
 	apiClient *client.Client
+	// watched holds the addresses given on the command line, loaded by connect
+	watched []watchlist.Entry
+	// network is the network selected by the system and network flags, set by connect
+	network client.Network
+	// registry holds the abis loaded from the abi-dir flag, see loadRegistry
+	registry *abiregistry.Registry
+	// selectors holds the embedded signatures and those imported by the selectors flag, see loadSelectors
+	selectors *fourbyte.DB
 )
 
 func main() {
 	app := cli.NewApp()
 	app.Name = "go-blocknative"
 	app.Usage = "cli for interacting with blocknative api"
-	app.Before = func(c *cli.Context) (err error) {
-		apiClient, err = client.New(c.Context, client.Opts{
-			Scheme: c.String("scheme"),
-			Host:   c.String("host"),
-			Path:   c.String("api.path"),
-			APIKey: c.String("api.key"),
-		})
-		if err != nil {
-			return
-		}
-		err = apiClient.Initialize(client.NewBaseMessageMainnet(c.String("api.key")))
-		return
-	}
 	app.Flags = []cli.Flag{
 		&cli.StringFlag{
 			Name:    "api.key",
 			EnvVars: []string{"BLOCKNATIVE_DAPP_ID"},
 			Usage:   "blocknative api key",
 		},
-		&cli.StringFlag{
+		&cli.StringSliceFlag{
 			Name:  "address",
-			Usage: "address to use when subscribing to events",
-			Value: "0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41",
+			Usage: "address to use when subscribing to events, may be repeated",
+		},
+		&cli.StringFlag{
+			Name:  "address-file",
+			Usage: "file listing addresses to subscribe to as a plain list, csv or json with labels, - reads stdin",
 		},
 		&cli.StringFlag{
 			Name:  "tx.hash",
 			Usage: "transaction hash to use when subscribing to events",
 		},
+		&cli.StringFlag{
+			Name:  "system",
+			Usage: "blockchain system of the network",
+			Value: "ethereum",
+		},
+		&cli.StringFlag{
+			Name:  "network",
+			Usage: "network to use given by name or chain id, e.g. main, goerli or 137",
+			Value: "main",
+		},
 		&cli.StringFlag{
 			Name:  "scheme",
 			Usage: "connection scheme to use",
@@ -60,48 +78,235 @@ func main() {
 			Usage: "api path to use",
 			Value: "/v0",
 		},
+		&cli.StringFlag{
+			Name:  "metrics.addr",
+			Usage: "address to serve prometheus metrics on at /metrics, disabled if empty",
+		},
+		&cli.StringFlag{
+			Name:  "admin.addr",
+			Usage: "address to serve the admin api for managing watches on, disabled if empty",
+		},
+		&cli.StringFlag{
+			Name:    "admin.token",
+			Usage:   "bearer token required by the admin api, needed when it is not served on a loopback address",
+			EnvVars: []string{"BLOCKNATIVE_ADMIN_TOKEN"},
+		},
+		&cli.StringSliceFlag{
+			Name:  "abi-dir",
+			Usage: "directory of abi json files and hardhat or foundry artifacts used in configs and to decode transaction inputs, may be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:  "selectors",
+			Usage: "text or json dump of function signatures added to the embedded selector database, may be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  "decode-selectors",
+			Usage: "decode the calls of transactions and internal transactions without an abi using the selector database",
+		},
+		&cli.IntFlag{
+			Name:  "reconnects",
+			Usage: "number of times to reconnect when the connection drops before giving up",
+			Value: 5,
+		},
+		&cli.StringFlag{
+			Name:  "history",
+			Usage: "file subscriptions are persisted to and restored from on startup, disabled if empty",
+		},
 	}
 	app.Commands = cli.Commands{
-		&cli.Command{
-			Name:    "subscribe",
-			Aliases: []string{"sub"},
-			Usage:   "event subscription commands",
-			Subcommands: cli.Commands{
-				&cli.Command{
-					Name:  "address",
-					Usage: "subscribe to events based on addresse",
-					Action: func(c *cli.Context) error {
-						if err := apiClient.WriteJSON(client.NewAddressSubscribe(
-							client.NewBaseMessageMainnet(
-								c.String("api.key"),
-							),
-							c.String("address"),
-						)); err != nil {
-							return err
-						}
-						for {
-							var out client.EthTxPayload
-							if err := apiClient.ReadJSON(&out); err != nil {
-								// used to ignore the following event
-								// websocket: close 1005 (no status)
-								if websocket.IsUnexpectedCloseError(err, 1005) {
-									log.Println("receive unexpected close, exiting: ", err)
-									break
-								} else {
-									log.Println("receive expected close message: ", err)
-									continue
-								}
-							}
-							log.Printf("receive message:\n%+v\n", out)
-						}
-						defer apiClient.Close()
-						return nil
-					},
-				},
-			},
-		},
+		subscribeCommand,
+		forwardCommand,
+		serveCommand,
+		queryCommand,
+		configCommand,
+		tuiCommand,
+		gasCommand,
+		syncCommand,
+		decodeCommand,
+		treeCommand,
 	}
 	if err := app.Run(os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// connect creates the api client and initializes the connection, it is
+// run before every command that talks to the api
+func connect(c *cli.Context) (err error) {
+	if network, err = client.LookupNetwork(c.String("system"), c.String("network")); err != nil {
+		return
+	}
+	if watched, err = loadWatchlist(c); err != nil {
+		return
+	}
+	if err = loadRegistry(c); err != nil {
+		return
+	}
+	if err = loadSelectors(c); err != nil {
+		return
+	}
+	var metrics *client.Metrics
+	if addr := c.String("metrics.addr"); addr != "" {
+		metrics = serveMetrics(addr)
+	}
+	var history client.History
+	if path := c.String("history"); path != "" {
+		if history, err = client.NewFileHistory(path); err != nil {
+			return
+		}
+	}
+	apiClient, err = client.New(c.Context, client.Opts{
+		Scheme:        c.String("scheme"),
+		Host:          c.String("host"),
+		Path:          c.String("api.path"),
+		APIKey:        c.String("api.key"),
+		Metrics:       metrics,
+		History:       history,
+		MaxReconnects: c.Int("reconnects"),
+	})
+	if err != nil {
+		if closer, ok := history.(io.Closer); ok {
+			closer.Close()
+		}
+		return
+	}
+	if err = apiClient.Initialize(client.NewBaseMessage(c.String("api.key"), network.Blockchain())); err != nil {
+		// closing the client closes the history as well
+		apiClient.Close()
+		return
+	}
+	if c.String("admin.addr") != "" {
+		if err = serveAdmin(c); err != nil {
+			apiClient.Close()
+		}
+	}
+	return
+}
+
+// loadWatchlist returns the addresses given by the address and address-file flags
+func loadWatchlist(c *cli.Context) ([]watchlist.Entry, error) {
+	flagged, err := watchlist.Parse(strings.NewReader(strings.Join(c.StringSlice("address"), "\n")))
+	if err != nil {
+		return nil, err
+	}
+	var listed []watchlist.Entry
+	switch path := c.String("address-file"); path {
+	case "":
+	case "-":
+		listed, err = watchlist.Parse(os.Stdin)
+	default:
+		var f *os.File
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		listed, err = watchlist.Parse(f)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading address file")
+	}
+	return watchlist.Merge(flagged, listed), nil
+}
+
+// loadRegistry loads the abis in the directories given by the abi-dir flag,
+// doing nothing once they are loaded
+func loadRegistry(c *cli.Context) error {
+	if registry != nil {
+		return nil
+	}
+	r := abiregistry.New()
+	for _, dir := range c.StringSlice("abi-dir") {
+		if err := r.LoadDir(dir); err != nil {
+			return errors.Wrapf(err, "loading abis dir:%v", dir)
+		}
+	}
+	registry = r
+	return nil
+}
+
+// loadSelectors loads the selector database with the dumps given by the
+// selectors flag, doing nothing once it is loaded
+func loadSelectors(c *cli.Context) error {
+	if selectors != nil {
+		return nil
+	}
+	db := fourbyte.Default()
+	for _, path := range c.StringSlice("selectors") {
+		_, skipped, err := db.ImportFile(path)
+		if err != nil {
+			return err
+		}
+		if skipped > 0 {
+			log.Printf("skipped invalid signatures path:%v count:%v", path, skipped)
+		}
+	}
+	selectors = db
+	return nil
+}
+
+// decoded sets the contract call of transactions which the api did not
+// decode before passing them to handler, using the abis in the registry and
+// then the selector database when enabled by the decode-selectors flag
+func decoded(c *cli.Context, handler client.Handler) client.Handler {
+	if c.Bool("decode-selectors") && selectors != nil {
+		handler = selectors.Handler(handler)
+	}
+	if registry != nil && len(registry.Contracts()) > 0 {
+		handler = registry.Handler(handler)
+	}
+	return handler
+}
+
+// watchFlags subscribes to the addresses and transaction hash given on the
+// command line, failing when nothing would be watched
+func watchFlags(c *cli.Context) error {
+	if len(watched) == 0 && c.String("tx.hash") == "" && c.String("admin.addr") == "" {
+		// subscriptions may also come from configs or be restored from the history
+		subs := apiClient.Subscriptions()
+		if len(subs.Addresses) == 0 && len(subs.TxHashes) == 0 && len(subs.Configs) == 0 {
+			return errors.New("nothing to watch, use --address, --address-file or --tx.hash")
+		}
+	}
+	for _, entry := range watched {
+		if err := apiClient.WatchAddress(c.Context, entry.Address); err != nil {
+			return err
+		}
+	}
+	if hash := c.String("tx.hash"); hash != "" {
+		if err := apiClient.WatchTx(c.Context, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listen watches the addresses and transaction hash given on the command line
+// and hands every event to handler until interrupted
+func listen(c *cli.Context, handler client.Handler) error {
+	defer apiClient.Close()
+	if err := watchFlags(c); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err := apiClient.Listen(ctx, decoded(c, handler))
+	if ctx.Err() != nil {
+		log.Println("shutting down")
+		return nil
+	}
+	return err
+}
+
+// serveMetrics registers the client metrics with the default prometheus
+// registry and serves them on addr in the background
+func serveMetrics(addr string) *client.Metrics {
+	metrics := client.NewMetrics(prometheus.DefaultRegisterer)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Println("metrics server exited: ", err)
+		}
+	}()
+	return metrics
+}
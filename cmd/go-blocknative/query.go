@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/store"
+	"github.com/urfave/cli/v2"
+)
+
+func dbFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "db",
+		Usage: "path of the sqlite database events are archived in",
+		Value: "go-blocknative.db",
+	}
+}
+
+var queryCommand = &cli.Command{
+	Name:  "query",
+	Usage: "look up events archived by forward sqlite",
+	Flags: []cli.Flag{dbFlag()},
+	Subcommands: cli.Commands{
+		&cli.Command{
+			Name:      "tx",
+			Usage:     "show every event received for a transaction",
+			ArgsUsage: "<hash>",
+			Action: queryAction(func(c *cli.Context, db *store.SQLite) ([]store.TxRecord, error) {
+				return db.TxEvents(c.Context, c.Args().First())
+			}),
+		},
+		&cli.Command{
+			Name:      "address",
+			Usage:     "show the latest events sent from, to or watched for an address",
+			ArgsUsage: "<address>",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "limit",
+					Usage: "maximum number of events to show",
+					Value: 50,
+				},
+			},
+			Action: queryAction(func(c *cli.Context, db *store.SQLite) ([]store.TxRecord, error) {
+				return db.AddressEvents(c.Context, c.Args().First(), c.Int("limit"))
+			}),
+		},
+		&cli.Command{
+			Name:      "block",
+			Usage:     "show the events of transactions included in a block",
+			ArgsUsage: "<number>",
+			Action: queryAction(func(c *cli.Context, db *store.SQLite) ([]store.TxRecord, error) {
+				number, err := strconv.ParseUint(c.Args().First(), 10, 64)
+				if err != nil {
+					return nil, errors.Errorf("invalid block number:%v", c.Args().First())
+				}
+				return db.BlockEvents(c.Context, number)
+			}),
+		},
+	},
+}
+
+// queryAction opens the database, runs query and prints the resulting records as a table
+func queryAction(query func(*cli.Context, *store.SQLite) ([]store.TxRecord, error)) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.ShowSubcommandHelp(c)
+		}
+		// opening would create an empty database in place of a mistyped path
+		if _, err := os.Stat(c.String("db")); err != nil {
+			return errors.Wrap(err, "opening database")
+		}
+		db, err := store.OpenSQLite(c.String("db"))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		records, err := query(c, db)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RECEIVED\tEVENT\tSTATUS\tHASH\tFROM\tTO\tVALUE\tBLOCK")
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+				r.ReceivedAt.Local().Format(time.RFC3339), r.EventCode, r.Status, r.Hash, r.From, r.To, r.Value, r.BlockNumber)
+		}
+		return w.Flush()
+	}
+}
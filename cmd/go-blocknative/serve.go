@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tiennampham23/go-blocknative/gateway"
+	"github.com/urfave/cli/v2"
+)
+
+var serveCommand = &cli.Command{
+	Name:   "serve",
+	Usage:  "share the upstream connection with downstream consumers over sse and websockets",
+	Before: connect,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "address to serve the gateway on",
+			Value: "localhost:8080",
+		},
+		&cli.StringSliceFlag{
+			Name:  "gateway.origins",
+			Usage: "origins besides the gateway's own allowed to open websockets, * allows any",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		defer apiClient.Close()
+		gw := gateway.New(apiClient)
+		srv := &http.Server{Addr: c.String("listen"), Handler: gw.Handler(c.StringSlice("gateway.origins")...)}
+		ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			log.Println("serving gateway on", srv.Addr)
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Println("gateway server exited: ", err)
+				stop()
+			}
+		}()
+		defer srv.Shutdown(context.Background())
+		err := apiClient.Listen(ctx, decoded(c, gw.Handle))
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	},
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+
+	"github.com/tiennampham23/go-blocknative/client"
+	"github.com/tiennampham23/go-blocknative/output"
+	"github.com/tiennampham23/go-blocknative/sink"
+	"github.com/tiennampham23/go-blocknative/watchlist"
+	"github.com/urfave/cli/v2"
+)
+
+var subscribeCommand = &cli.Command{
+	Name:    "subscribe",
+	Aliases: []string{"sub"},
+	Usage:   "event subscription commands",
+	Subcommands: cli.Commands{
+		&cli.Command{
+			Name:   "address",
+			Usage:  "subscribe to events based on addresse",
+			Before: connect,
+			Flags:  append(outputFlags(), execFlags()...),
+			Action: func(c *cli.Context) error {
+				w, err := newOutputWriter(c)
+				if err != nil {
+					return err
+				}
+				defer w.Close()
+				if c.String("exec") == "" {
+					return listen(c, w.Write)
+				}
+				run, err := newExec(c)
+				if err != nil {
+					return err
+				}
+				defer run.Close()
+				return listen(c, func(ctx context.Context, msg *client.EthTxPayload) error {
+					if err := w.Write(ctx, msg); err != nil {
+						return err
+					}
+					return run.Write(ctx, msg)
+				})
+			},
+		},
+	},
+}
+
+func outputFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "output format, one of json, ndjson, csv, table, template or tree",
+			Value:   output.FormatNDJSON,
+		},
+		&cli.StringSliceFlag{
+			Name:  "columns",
+			Usage: "columns written by the csv and table formats",
+			Value: cli.NewStringSlice(output.DefaultColumns...),
+		},
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "go text/template executed for every event by the template format",
+		},
+		&cli.StringFlag{
+			Name:  "output.file",
+			Usage: "file to write events to instead of stdout",
+		},
+		&cli.Int64Flag{
+			Name:  "output.max-size",
+			Usage: "size in megabytes after which the output file is rotated, 0 disables rotation",
+			Value: 100,
+		},
+		&cli.IntFlag{
+			Name:  "output.max-files",
+			Usage: "number of rotated output files to keep",
+			Value: 5,
+		},
+	}
+}
+
+func execFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "exec",
+			Usage: "shell command run for every event with the event json on stdin and BN_* environment variables",
+		},
+		&cli.IntFlag{
+			Name:  "exec.concurrency",
+			Usage: "maximum number of commands running at once",
+			Value: 4,
+		},
+		&cli.DurationFlag{
+			Name:  "exec.timeout",
+			Usage: "time after which a command is killed",
+			Value: sink.DefaultExecTimeout,
+		},
+		&cli.StringFlag{
+			Name:  "exec.filter",
+			Usage: "go text/template run against every event, commands only run for events where it outputs true",
+		},
+	}
+}
+
+// newExec returns the sink running the exec command, its output is written
+// to stderr so that it does not mix with the events written to stdout
+func newExec(c *cli.Context) (sink.Sink, error) {
+	run, err := sink.NewExec(sink.ExecOpts{
+		Command:     c.String("exec"),
+		Concurrency: c.Int("exec.concurrency"),
+		Timeout:     c.Duration("exec.timeout"),
+		Stdout:      os.Stderr,
+		Stderr:      os.Stderr,
+		OnError: func(msg *client.EthTxPayload, err error) {
+			log.Printf("exec failed hash:%v reason:%v", msg.Event.Transaction.Hash, err)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expr := c.String("exec.filter"); expr != "" {
+		filter, err := sink.NewFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		return sink.Filtered(run, filter), nil
+	}
+	return run, nil
+}
+
+// newOutputWriter returns a writer for the format and destination selected on the command line
+func newOutputWriter(c *cli.Context) (*output.Writer, error) {
+	enc, err := output.NewEncoder(output.Options{
+		Format:     c.String("output"),
+		Columns:    c.StringSlice("columns"),
+		Template:   c.String("template"),
+		Labels:     watchlist.Labels(watched),
+		Blockchain: network.Blockchain(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var out io.Writer = nopCloser{os.Stdout}
+	if path := c.String("output.file"); path != "" {
+		if out, err = output.NewRotatingFile(path, c.Int64("output.max-size")<<20, c.Int("output.max-files")); err != nil {
+			return nil, err
+		}
+	}
+	return output.NewWriter(out, enc), nil
+}
+
+// nopCloser prevents the writer from closing stdout
+type nopCloser struct {
+	io.Writer
+}
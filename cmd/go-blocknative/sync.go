@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tiennampham23/go-blocknative/client"
+	"github.com/tiennampham23/go-blocknative/configfile"
+	"github.com/tiennampham23/go-blocknative/reconcile"
+	"github.com/urfave/cli/v2"
+)
+
+var syncCommand = &cli.Command{
+	Name:  "sync",
+	Usage: "converge the subscriptions of the connection, restored with --history, on those declared in a file",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:     "file",
+			Aliases:  []string{"f"},
+			Usage:    "yaml or json file declaring the addresses, txHashes and configs to subscribe to",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "print the changes without applying them",
+		},
+		&cli.BoolFlag{
+			Name:  "stream",
+			Usage: "stay connected and write events after syncing",
+		},
+	}, outputFlags()...),
+	Action: func(c *cli.Context) error {
+		// validate before connecting so that mistakes are reported without touching the api
+		desired, err := configfile.LoadSubscriptions(c.String("file"))
+		if err != nil {
+			return err
+		}
+		if err := loadRegistry(c); err != nil {
+			return err
+		}
+		registry.FillABIs(desired.Configs)
+		if c.Bool("dry-run") {
+			// plan against the history without connecting, which would restore its subscriptions
+			var current client.Subscriptions
+			if path := c.String("history"); path != "" {
+				if current, err = client.HistorySubscriptions(path); err != nil {
+					return err
+				}
+			}
+			actions, err := reconcile.Plan(current, desired)
+			printActions(actions)
+			return err
+		}
+		if err := connect(c); err != nil {
+			return err
+		}
+		actions, err := reconcile.New(apiClient, false).Reconcile(c.Context, desired)
+		printActions(actions)
+		if err != nil || !c.Bool("stream") {
+			apiClient.Close()
+			return err
+		}
+		w, err := newOutputWriter(c)
+		if err != nil {
+			apiClient.Close()
+			return err
+		}
+		defer w.Close()
+		return listen(c, w.Write)
+	},
+}
+
+func printActions(actions []reconcile.Action) {
+	for _, action := range actions {
+		fmt.Println(action)
+	}
+	if len(actions) == 0 {
+		fmt.Println("subscriptions are in sync")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/tiennampham23/go-blocknative/output"
+	"github.com/tiennampham23/go-blocknative/tui"
+	"github.com/urfave/cli/v2"
+)
+
+var treeCommand = &cli.Command{
+	Name:      "tree",
+	Usage:     "print the call trees and ether flows of recorded ndjson events",
+	ArgsUsage: "[file|-]",
+	Action: func(c *cli.Context) error {
+		var in io.Reader = os.Stdin
+		if path := c.Args().First(); path != "" && path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		msgs, err := tui.ReadEvents(in)
+		if err != nil {
+			return err
+		}
+		if err := loadRegistry(c); err != nil {
+			return err
+		}
+		if err := loadSelectors(c); err != nil {
+			return err
+		}
+		enc, err := output.NewEncoder(output.Options{Format: output.FormatTree})
+		if err != nil {
+			return err
+		}
+		handler := decoded(c, output.NewWriter(os.Stdout, enc).Write)
+		for _, msg := range msgs {
+			if err := handler(c.Context, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tiennampham23/go-blocknative/client"
+	"github.com/tiennampham23/go-blocknative/tui"
+	"github.com/urfave/cli/v2"
+)
+
+var tuiCommand = &cli.Command{
+	Name:  "tui",
+	Usage: "live dashboard of subscribed events",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "max-rows",
+			Usage: "number of transactions kept in the dashboard",
+			Value: tui.DefaultMaxRows,
+		},
+		&cli.StringFlag{
+			Name:  "replay",
+			Usage: "ndjson file of events to replay instead of connecting to the api",
+		},
+		&cli.DurationFlag{
+			Name:  "replay.interval",
+			Usage: "delay between replayed events",
+			Value: 200 * time.Millisecond,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		ctx, cancel := context.WithCancel(c.Context)
+		defer cancel()
+		events, errc, err := dashboardEvents(ctx, c)
+		if err != nil {
+			return err
+		}
+		if _, err := tea.NewProgram(tui.New(events, c.Int("max-rows")), tea.WithAltScreen()).Run(); err != nil {
+			return err
+		}
+		cancel()
+		if c.String("replay") == "" {
+			// unblocks the pending read of the listener
+			apiClient.Close()
+		}
+		return <-errc
+	},
+}
+
+// dashboardEvents returns the events shown by the dashboard, replayed from
+// a file or received from the api, along with the error that ended them
+func dashboardEvents(ctx context.Context, c *cli.Context) (<-chan *client.EthTxPayload, <-chan error, error) {
+	errc := make(chan error, 1)
+	if path := c.String("replay"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		msgs, err := tui.ReadEvents(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		errc <- nil
+		return tui.Replay(ctx, msgs, c.Duration("replay.interval")), errc, nil
+	}
+	if err := connect(c); err != nil {
+		return nil, nil, err
+	}
+	if err := watchFlags(c); err != nil {
+		apiClient.Close()
+		return nil, nil, err
+	}
+	events := make(chan *client.EthTxPayload)
+	go func() {
+		defer close(events)
+		err := apiClient.Listen(ctx, decoded(c, func(ctx context.Context, msg *client.EthTxPayload) error {
+			select {
+			case events <- msg:
+			case <-ctx.Done():
+			}
+			return nil
+		}))
+		if ctx.Err() != nil {
+			err = nil
+		}
+		errc <- err
+	}()
+	return events, errc, nil
+}
@@ -0,0 +1,141 @@
+// Package configfile loads blocknative configurations and subscriptions declared in yaml or json files
+package configfile
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/abiregistry"
+	"github.com/tiennampham23/go-blocknative/client"
+	"gopkg.in/yaml.v3"
+)
+
+// Entry declares a single configuration
+type Entry struct {
+	// Scope is 'global' or the address the configuration applies to
+	Scope string `yaml:"scope"`
+	// Filters are jsql filters, see client.Config
+	Filters []map[string]string `yaml:"filters"`
+	// ABI is the path of a json abi file or contract artifact, relative
+	// paths are resolved against the directory of the configuration file
+	ABI string `yaml:"abi"`
+	// WatchAddress sets client.Config.WatchAddress
+	WatchAddress bool `yaml:"watchAddress"`
+	// Simulate sets client.Config.Simulate
+	Simulate bool `yaml:"simulate"`
+}
+
+// File is the structure of a configuration file, which may also be a plain
+// list of entries. Addresses and TxHashes are only used when the file
+// describes the desired subscriptions of a client.
+type File struct {
+	Configs   []Entry  `yaml:"configs"`
+	Addresses []string `yaml:"addresses"`
+	TxHashes  []string `yaml:"txHashes"`
+}
+
+// Load reads the configuration file at path, returning a validated client.Config for every entry
+func Load(path string) ([]client.Config, error) {
+	subs, err := LoadSubscriptions(path)
+	if err != nil {
+		return nil, err
+	}
+	return subs.Configs, nil
+}
+
+// LoadSubscriptions reads the configurations, addresses and transaction
+// hashes declared in the file at path, validating all of them
+func LoadSubscriptions(path string) (client.Subscriptions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return client.Subscriptions{}, err
+	}
+	file, err := ParseFile(data)
+	if err != nil {
+		return client.Subscriptions{}, err
+	}
+	subs := client.Subscriptions{
+		Addresses: file.Addresses,
+		TxHashes:  file.TxHashes,
+		Configs:   make([]client.Config, len(file.Configs)),
+	}
+	for i, entry := range file.Configs {
+		if subs.Configs[i], err = entry.Config(filepath.Dir(path)); err != nil {
+			return client.Subscriptions{}, errors.Wrapf(err, "config %d scope:%v", i, entry.Scope)
+		}
+	}
+	for _, address := range subs.Addresses {
+		if !common.IsHexAddress(address) {
+			return client.Subscriptions{}, errors.Errorf("invalid address:%v", address)
+		}
+	}
+	for _, hash := range subs.TxHashes {
+		if !isTxHash(hash) {
+			return client.Subscriptions{}, errors.Errorf("invalid transaction hash:%v", hash)
+		}
+	}
+	return subs, nil
+}
+
+func isTxHash(s string) bool {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s) != 2*common.HashLength {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Parse decodes the entries of a yaml or json configuration file
+func Parse(data []byte) ([]Entry, error) {
+	file, err := ParseFile(data)
+	return file.Configs, err
+}
+
+// ParseFile decodes a yaml or json configuration file
+func ParseFile(data []byte) (File, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return File{}, errors.Wrap(err, "decoding config file")
+	}
+	if len(doc.Content) == 0 {
+		return File{}, nil
+	}
+	root := doc.Content[0]
+	var file File
+	if root.Kind == yaml.SequenceNode {
+		if err := root.Decode(&file.Configs); err != nil {
+			return File{}, errors.Wrap(err, "decoding config file")
+		}
+		return file, nil
+	}
+	if err := root.Decode(&file); err != nil {
+		return File{}, errors.Wrap(err, "decoding config file")
+	}
+	return file, nil
+}
+
+// Config returns the validated client.Config for the entry, loading its
+// abi relative to dir
+func (e Entry) Config(dir string) (client.Config, error) {
+	var abis interface{}
+	if e.ABI != "" {
+		path := e.ABI
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		contract, err := abiregistry.ReadFile(path)
+		if err != nil {
+			return client.Config{}, errors.Wrapf(err, "invalid abi:%v", e.ABI)
+		}
+		abis = contract.JSON()
+	}
+	cfg := client.NewConfig(e.Scope, e.WatchAddress, abis)
+	cfg.Filters = e.Filters
+	cfg.Simulate = e.Simulate
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,74 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	configs, err := Load(filepath.Join("testdata", "configs.yaml"))
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	require.Equal(t, "0xdAC17F958D2ee523a2206206994597C13D831ec7", configs[0].Scope)
+	require.True(t, configs[0].WatchAddress)
+	require.True(t, configs[0].Simulate)
+	require.False(t, configs[1].Simulate)
+	require.Equal(t, []map[string]string{{"contractCall.methodName": "transfer", "_propertySearch": "true"}}, configs[0].Filters)
+	require.Len(t, configs[0].ABI, 1)
+	require.Equal(t, "global", configs[1].Scope)
+	require.Nil(t, configs[1].ABI)
+}
+
+func TestLoadJSONList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "configs.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"scope": "global", "filters": [{"status": "pending"}]}]`), 0o644))
+	configs, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	require.Equal(t, "pending", configs[0].Filters[0]["status"])
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"scope.yaml":  "- scope: nope\n",
+		"watch.yaml":  "- scope: global\n  watchAddress: true\n",
+		"filter.yaml": "- scope: global\n  filters: [{}]\n",
+		"abi.yaml":    "- scope: global\n  abi: missing.json\n",
+		"badabi.yaml": "- scope: global\n  abi: badabi.yaml\n",
+		"syntax.yaml": "configs: [\n",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := Load(path)
+		require.Error(t, err, name)
+	}
+}
+
+func TestLoadSubscriptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	hash := "0x" + strings.Repeat("ab", 32)
+	require.NoError(t, os.WriteFile(path, []byte(`
+addresses:
+  - "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+txHashes:
+  - "`+hash+`"
+configs:
+  - scope: global
+`), 0o644))
+	subs, err := LoadSubscriptions(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0xdAC17F958D2ee523a2206206994597C13D831ec7"}, subs.Addresses)
+	require.Equal(t, []string{hash}, subs.TxHashes)
+	require.Len(t, subs.Configs, 1)
+
+	for _, content := range []string{"addresses: [nope]\n", "txHashes: [0x01]\n"} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err = LoadSubscriptions(path)
+		require.Error(t, err, content)
+	}
+}
@@ -0,0 +1,436 @@
+// Package dex decodes swaps made through uniswap style routers into
+// normalized trade records. Calls are read from the contract call decoded by
+// the api, falling back to decoding the raw transaction input, so that forks
+// with the same interface such as sushiswap are decoded as well.
+package dex
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// ErrNotSwap is returned for transactions which do not call a known swap method
+var ErrNotSwap = errors.New("not a swap")
+
+// Protocols of the pools a swap is routed through
+const (
+	V2 = "v2"
+	V3 = "v3"
+)
+
+var (
+	// msgSender and addressThis are the placeholder recipients used by the
+	// v3 and universal routers for the caller and the router itself
+	msgSender   = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	addressThis = common.HexToAddress("0x0000000000000000000000000000000000000002")
+)
+
+// Swap is a trade decoded from a router call. For exact output swaps AmountIn
+// is the maximum input and MinOut the exact output.
+type Swap struct {
+	Protocol string `json:"protocol"`
+	// Method is the router method or universal router command
+	Method   string           `json:"method"`
+	TokenIn  common.Address   `json:"tokenIn"`
+	TokenOut common.Address   `json:"tokenOut"`
+	AmountIn *big.Int         `json:"amountIn"`
+	MinOut   *big.Int         `json:"minOut"`
+	ExactOut bool             `json:"exactOut,omitempty"`
+	Path     []common.Address `json:"path"`
+	// Fees are the fees of the v3 pools along the path in hundredths of a bip
+	Fees []uint32 `json:"fees,omitempty"`
+	// Deadline is the unix timestamp the swap must be included before, 0 if the call has none
+	Deadline  uint64         `json:"deadline,omitempty"`
+	Recipient common.Address `json:"recipient"`
+}
+
+// Decode returns the swaps made by the transaction of msg. Multicalls and
+// universal router calls may hold several swaps.
+func Decode(msg *client.EthTxPayload) ([]Swap, error) {
+	return DecodeTx(&msg.Event.Transaction)
+}
+
+// DecodeTx returns the swaps made by tx
+func DecodeTx(tx *client.TransactionPayload) ([]Swap, error) {
+	// params the api decoded in an unexpected shape are decoded again from the input
+	var callErr error
+	if call := tx.ContractCall; call != nil && call.Params != nil {
+		swaps, err := decodeCall(tx, call.MethodName, call.Params, 0)
+		if err == nil {
+			return swaps, nil
+		}
+		if err != ErrNotSwap {
+			callErr = err
+		}
+	}
+	swaps, err := decodeTxInput(tx)
+	if err != nil && callErr != nil {
+		return nil, callErr
+	}
+	return swaps, err
+}
+
+// decodeTxInput decodes the swaps of the raw input of tx
+func decodeTxInput(tx *client.TransactionPayload) ([]Swap, error) {
+	input, err := hexutil.Decode(tx.Input)
+	if err != nil {
+		return nil, ErrNotSwap
+	}
+	method, params, err := DecodeInput(input)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCall(tx, method, params, 0)
+}
+
+// decodeCall decodes the swaps of a router method call, deadline is that of
+// an enclosing multicall
+func decodeCall(tx *client.TransactionPayload, method string, p client.Params, deadline uint64) ([]Swap, error) {
+	if d, ok := uintParam(p, "deadline"); ok {
+		deadline = d
+	}
+	var (
+		swap Swap
+		err  error
+	)
+	switch method {
+	case "swapExactTokensForTokens", "swapExactTokensForETH",
+		"swapExactTokensForTokensSupportingFeeOnTransferTokens", "swapExactTokensForETHSupportingFeeOnTransferTokens":
+		swap, err = v2Swap(p, "amountIn", "amountOutMin", false)
+	case "swapExactETHForTokens", "swapExactETHForTokensSupportingFeeOnTransferTokens":
+		swap, err = v2Swap(p, "", "amountOutMin", false)
+		swap.AmountIn = value(tx)
+	case "swapTokensForExactTokens", "swapTokensForExactETH":
+		swap, err = v2Swap(p, "amountInMax", "amountOut", true)
+	case "swapETHForExactTokens":
+		swap, err = v2Swap(p, "", "amountOut", true)
+		swap.AmountIn = value(tx)
+	case "exactInputSingle", "exactOutputSingle":
+		swap, err = v3SingleSwap(p, method == "exactOutputSingle")
+	case "exactInput", "exactOutput":
+		swap, err = v3Swap(p, method == "exactOutput")
+	case "multicall":
+		return decodeMulticall(tx, p, deadline)
+	case "execute":
+		return decodeExecute(tx, p, deadline)
+	default:
+		return nil, ErrNotSwap
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding swap method:%v", method)
+	}
+	swap.Method = method
+	if swap.Deadline == 0 {
+		swap.Deadline = deadline
+	}
+	swap.Recipient = recipient(tx, swap.Recipient)
+	return []Swap{swap}, nil
+}
+
+// v2Swap decodes the params of a v2 router method, in and out name the
+// params holding the input and output amounts
+func v2Swap(p client.Params, in, out string, exactOut bool) (Swap, error) {
+	swap := Swap{Protocol: V2, ExactOut: exactOut}
+	var err error
+	if in != "" {
+		if swap.AmountIn, err = bigIntParam(p, in); err != nil {
+			return swap, err
+		}
+	}
+	if swap.MinOut, err = bigIntParam(p, out); err != nil {
+		return swap, err
+	}
+	if swap.Path, err = addressesParam(p, "path"); err != nil {
+		return swap, err
+	}
+	swap.TokenIn, swap.TokenOut = swap.Path[0], swap.Path[len(swap.Path)-1]
+	swap.Recipient, err = addressParam(p, "to")
+	return swap, err
+}
+
+// v3SingleSwap decodes the params of exactInputSingle and exactOutputSingle
+func v3SingleSwap(p client.Params, exactOut bool) (Swap, error) {
+	swap := Swap{Protocol: V3, ExactOut: exactOut}
+	p, ok := p.Tuple("params")
+	if !ok {
+		return swap, errors.New("missing params tuple")
+	}
+	var err error
+	if swap.TokenIn, err = addressParam(p, "tokenIn"); err != nil {
+		return swap, err
+	}
+	if swap.TokenOut, err = addressParam(p, "tokenOut"); err != nil {
+		return swap, err
+	}
+	fee, ok := uintParam(p, "fee")
+	if !ok {
+		return swap, errors.New("invalid param:fee")
+	}
+	swap.Path, swap.Fees = []common.Address{swap.TokenIn, swap.TokenOut}, []uint32{uint32(fee)}
+	if swap.Recipient, err = addressParam(p, "recipient"); err != nil {
+		return swap, err
+	}
+	swap.Deadline, _ = uintParam(p, "deadline")
+	in, out := "amountIn", "amountOutMinimum"
+	if exactOut {
+		in, out = "amountInMaximum", "amountOut"
+	}
+	if swap.AmountIn, err = bigIntParam(p, in); err != nil {
+		return swap, err
+	}
+	swap.MinOut, err = bigIntParam(p, out)
+	return swap, err
+}
+
+// v3Swap decodes the params of exactInput and exactOutput
+func v3Swap(p client.Params, exactOut bool) (Swap, error) {
+	p, ok := p.Tuple("params")
+	if !ok {
+		return Swap{}, errors.New("missing params tuple")
+	}
+	in, out := "amountIn", "amountOutMinimum"
+	if exactOut {
+		in, out = "amountInMaximum", "amountOut"
+	}
+	swap, err := v3PathSwap(p, in, out, exactOut)
+	if err != nil {
+		return swap, err
+	}
+	swap.Deadline, _ = uintParam(p, "deadline")
+	return swap, nil
+}
+
+// v3PathSwap decodes a swap along an encoded v3 path
+func v3PathSwap(p client.Params, in, out string, exactOut bool) (Swap, error) {
+	swap := Swap{Protocol: V3, ExactOut: exactOut}
+	encoded, err := bytesParam(p, "path")
+	if err != nil {
+		return swap, err
+	}
+	if swap.Path, swap.Fees, err = DecodePath(encoded); err != nil {
+		return swap, err
+	}
+	// exact output paths are encoded from the output token to the input
+	if exactOut {
+		reverse(swap.Path, swap.Fees)
+	}
+	swap.TokenIn, swap.TokenOut = swap.Path[0], swap.Path[len(swap.Path)-1]
+	if swap.Recipient, err = addressParam(p, "recipient"); err != nil {
+		return swap, err
+	}
+	if swap.AmountIn, err = bigIntParam(p, in); err != nil {
+		return swap, err
+	}
+	swap.MinOut, err = bigIntParam(p, out)
+	return swap, err
+}
+
+// decodeMulticall decodes the swaps of the calls bundled by a v3 router multicall
+func decodeMulticall(tx *client.TransactionPayload, p client.Params, deadline uint64) ([]Swap, error) {
+	calls, ok := p.Strings("data")
+	if !ok {
+		return nil, errors.New("invalid multicall param:data")
+	}
+	var swaps []Swap
+	for i, data := range calls {
+		input, err := hexutil.Decode(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid multicall data index:%v", i)
+		}
+		method, params, err := DecodeInput(input)
+		if err == ErrNotSwap {
+			// calls such as refundETH and unwrapWETH9 move no tokens through pools
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := decodeCall(tx, method, params, deadline)
+		if err != nil && err != ErrNotSwap {
+			return nil, err
+		}
+		swaps = append(swaps, decoded...)
+	}
+	if len(swaps) == 0 {
+		return nil, ErrNotSwap
+	}
+	return swaps, nil
+}
+
+// Universal router commands decoded as swaps, the command type is held by the low 6 bits of each command byte
+const (
+	commandMask    = 0x3f
+	v3SwapExactIn  = 0x00
+	v3SwapExactOut = 0x01
+	v2SwapExactIn  = 0x08
+	v2SwapExactOut = 0x09
+	commandV3In    = "V3_SWAP_EXACT_IN"
+	commandV3Out   = "V3_SWAP_EXACT_OUT"
+	commandV2In    = "V2_SWAP_EXACT_IN"
+	commandV2Out   = "V2_SWAP_EXACT_OUT"
+)
+
+// decodeExecute decodes the swap commands of a universal router call
+func decodeExecute(tx *client.TransactionPayload, p client.Params, deadline uint64) ([]Swap, error) {
+	commandBytes, err := bytesParam(p, "commands")
+	if err != nil {
+		return nil, err
+	}
+	inputs, ok := p.Strings("inputs")
+	if !ok || len(inputs) != len(commandBytes) {
+		return nil, errors.New("invalid execute param:inputs")
+	}
+	var swaps []Swap
+	for i, command := range commandBytes {
+		var name string
+		switch command & commandMask {
+		case v3SwapExactIn:
+			name = commandV3In
+		case v3SwapExactOut:
+			name = commandV3Out
+		case v2SwapExactIn:
+			name = commandV2In
+		case v2SwapExactOut:
+			name = commandV2Out
+		default:
+			continue
+		}
+		input, err := hexutil.Decode(inputs[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid execute input index:%v", i)
+		}
+		params, err := client.UnpackParams(commands.Methods[name].Inputs, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding command:%v", name)
+		}
+		var swap Swap
+		switch name {
+		case commandV3In:
+			swap, err = v3PathSwap(params, "amountIn", "amountOutMin", false)
+		case commandV3Out:
+			swap, err = v3PathSwap(params, "amountInMax", "amountOut", true)
+		case commandV2In:
+			swap, err = v2Swap(renameRecipient(params), "amountIn", "amountOutMin", false)
+		case commandV2Out:
+			swap, err = v2Swap(renameRecipient(params), "amountInMax", "amountOut", true)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding command:%v", name)
+		}
+		swap.Method, swap.Deadline = name, deadline
+		swap.Recipient = recipient(tx, swap.Recipient)
+		swaps = append(swaps, swap)
+	}
+	if len(swaps) == 0 {
+		return nil, ErrNotSwap
+	}
+	return swaps, nil
+}
+
+// renameRecipient names the recipient of universal router v2 commands as the v2 router does
+func renameRecipient(p client.Params) client.Params {
+	p["to"] = p["recipient"]
+	return p
+}
+
+// DecodePath decodes a v3 path of token addresses separated by 3 byte pool fees
+func DecodePath(path []byte) ([]common.Address, []uint32, error) {
+	const hop = common.AddressLength + 3
+	if len(path) < common.AddressLength+hop || (len(path)-common.AddressLength)%hop != 0 {
+		return nil, nil, errors.Errorf("invalid v3 path length:%v", len(path))
+	}
+	var (
+		tokens []common.Address
+		fees   []uint32
+	)
+	for i := 0; ; i += hop {
+		tokens = append(tokens, common.BytesToAddress(path[i:i+common.AddressLength]))
+		if i+common.AddressLength == len(path) {
+			return tokens, fees, nil
+		}
+		fee := path[i+common.AddressLength : i+hop]
+		fees = append(fees, uint32(fee[0])<<16|uint32(fee[1])<<8|uint32(fee[2]))
+	}
+}
+
+func reverse(tokens []common.Address, fees []uint32) {
+	for i, j := 0, len(tokens)-1; i < j; i, j = i+1, j-1 {
+		tokens[i], tokens[j] = tokens[j], tokens[i]
+	}
+	for i, j := 0, len(fees)-1; i < j; i, j = i+1, j-1 {
+		fees[i], fees[j] = fees[j], fees[i]
+	}
+}
+
+// recipient resolves the placeholder recipients to the sender and the router
+func recipient(tx *client.TransactionPayload, addr common.Address) common.Address {
+	switch {
+	case addr == msgSender && common.IsHexAddress(tx.From):
+		return common.HexToAddress(tx.From)
+	case addr == addressThis && common.IsHexAddress(tx.To):
+		return common.HexToAddress(tx.To)
+	}
+	return addr
+}
+
+// value returns the ether sent by tx, swapped for tokens by the eth router methods
+func value(tx *client.TransactionPayload) *big.Int {
+	v, ok := client.ParseAmount(tx.Value)
+	if !ok {
+		return new(big.Int)
+	}
+	return v
+}
+
+func bigIntParam(p client.Params, name string) (*big.Int, error) {
+	v, ok := p.BigInt(name)
+	if !ok {
+		return nil, errors.Errorf("invalid param:%v", name)
+	}
+	return v, nil
+}
+
+func uintParam(p client.Params, name string) (uint64, bool) {
+	v, ok := p.BigInt(name)
+	if !ok || !v.IsUint64() {
+		return 0, false
+	}
+	return v.Uint64(), true
+}
+
+func addressParam(p client.Params, name string) (common.Address, error) {
+	v, ok := p.Address(name)
+	if !ok {
+		return common.Address{}, errors.Errorf("invalid param:%v", name)
+	}
+	return v, nil
+}
+
+func addressesParam(p client.Params, name string) ([]common.Address, error) {
+	values, ok := p.Strings(name)
+	if !ok || len(values) < 2 {
+		return nil, errors.Errorf("invalid param:%v", name)
+	}
+	out := make([]common.Address, len(values))
+	for i, v := range values {
+		if !common.IsHexAddress(v) {
+			return nil, errors.Errorf("invalid param:%v", name)
+		}
+		out[i] = common.HexToAddress(v)
+	}
+	return out, nil
+}
+
+func bytesParam(p client.Params, name string) ([]byte, error) {
+	v, ok := p.String(name)
+	if !ok || !strings.HasPrefix(v, "0x") {
+		return nil, errors.Errorf("invalid param:%v", name)
+	}
+	b, err := hexutil.Decode(v)
+	return b, errors.Wrapf(err, "invalid param:%v", name)
+}
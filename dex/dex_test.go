@@ -0,0 +1,137 @@
+package dex
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+var (
+	weth = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	dai  = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	user = common.HexToAddress("0x1111111111111111111111111111111111111111")
+)
+
+func amount(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
+
+// TestDecode decodes the calldata in testdata/transactions.json. The
+// fixtures are encoded from the router abis rather than recorded from
+// mainnet; they should be replaced with calldata of real transactions,
+// noting their hashes, once they can be fetched.
+func TestDecode(t *testing.T) {
+	data, err := os.ReadFile("testdata/transactions.json")
+	require.NoError(t, err)
+	var txs map[string]client.TransactionPayload
+	require.NoError(t, json.Unmarshal(data, &txs))
+
+	tests := []struct {
+		name  string
+		swaps []Swap
+		err   string
+	}{
+		{
+			name: "v2SwapExactETHForTokens",
+			swaps: []Swap{{Protocol: V2, Method: "swapExactETHForTokens", TokenIn: weth, TokenOut: usdc,
+				AmountIn: amount("1000000000000000000"), MinOut: amount("1800000000"),
+				Path: []common.Address{weth, usdc}, Deadline: 1700000000, Recipient: user}},
+		},
+		{
+			name: "v2SwapTokensForExactETH",
+			swaps: []Swap{{Protocol: V2, Method: "swapTokensForExactETH", TokenIn: usdc, TokenOut: weth,
+				AmountIn: amount("1000000000"), MinOut: amount("500000000000000000"), ExactOut: true,
+				Path: []common.Address{usdc, weth}, Deadline: 1700000000, Recipient: user}},
+		},
+		{
+			name: "v3ExactInputSingle",
+			swaps: []Swap{{Protocol: V3, Method: "exactInputSingle", TokenIn: usdc, TokenOut: weth,
+				AmountIn: amount("2000000000"), MinOut: amount("1000000000000000000"),
+				Path: []common.Address{usdc, weth}, Fees: []uint32{500}, Deadline: 1700000000, Recipient: user}},
+		},
+		{
+			// SwapRouter02 methods take no deadline
+			name: "v3ExactInputRouter02",
+			swaps: []Swap{{Protocol: V3, Method: "exactInput", TokenIn: usdc, TokenOut: dai,
+				AmountIn: amount("1000000000"), MinOut: amount("990000000000000000000"),
+				Path: []common.Address{usdc, weth, dai}, Fees: []uint32{500, 3000}, Recipient: user}},
+		},
+		{
+			// exact output paths are reversed into trade order
+			name: "v3ExactOutput",
+			swaps: []Swap{{Protocol: V3, Method: "exactOutput", TokenIn: weth, TokenOut: dai,
+				AmountIn: amount("600000000000000000"), MinOut: amount("1000000000000000000000"), ExactOut: true,
+				Path: []common.Address{weth, usdc, dai}, Fees: []uint32{500, 100}, Deadline: 1700000000, Recipient: user}},
+		},
+		{
+			// the unwrapWETH9 call is skipped, the router placeholder recipient resolves to the router
+			name: "v3Multicall",
+			swaps: []Swap{{Protocol: V3, Method: "exactInputSingle", TokenIn: usdc, TokenOut: weth,
+				AmountIn: amount("3000000000"), MinOut: amount("1500000000000000000"),
+				Path: []common.Address{usdc, weth}, Fees: []uint32{3000}, Deadline: 1700000000,
+				Recipient: common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45")}},
+		},
+		{
+			// the WRAP_ETH command is skipped, the sender placeholder recipient resolves to the sender
+			name: "universalV3ExactIn",
+			swaps: []Swap{{Protocol: V3, Method: "V3_SWAP_EXACT_IN", TokenIn: weth, TokenOut: usdc,
+				AmountIn: amount("1000000000000000000"), MinOut: amount("1790000000"),
+				Path: []common.Address{weth, usdc}, Fees: []uint32{500}, Deadline: 1700000000, Recipient: user}},
+		},
+		{
+			// commands flagged to allow reverting are decoded as well
+			name: "universalV2ExactOut",
+			swaps: []Swap{{Protocol: V2, Method: "V2_SWAP_EXACT_OUT", TokenIn: usdc, TokenOut: dai,
+				AmountIn: amount("1100000000"), MinOut: amount("1000000000000000000000"), ExactOut: true,
+				Path: []common.Address{usdc, dai}, Recipient: user}},
+		},
+		{
+			// the contract call decoded by the api is used over the input
+			name: "sushiContractCall",
+			swaps: []Swap{{Protocol: V2, Method: "swapExactTokensForTokens", TokenIn: usdc, TokenOut: dai,
+				AmountIn: amount("250000000"), MinOut: amount("249000000000000000000"),
+				Path: []common.Address{usdc, dai}, Deadline: 1700000000, Recipient: user}},
+		},
+		{
+			// params the api decoded in an unexpected shape fall back to the input
+			name: "malformedContractCall",
+			swaps: []Swap{{Protocol: V2, Method: "swapExactETHForTokens", TokenIn: weth, TokenOut: usdc,
+				AmountIn: amount("1000000000000000000"), MinOut: amount("1800000000"),
+				Path: []common.Address{weth, usdc}, Deadline: 1700000000, Recipient: user}},
+		},
+		{name: "malformedContractCallTruncated", err: "invalid param:path"},
+		{name: "erc20Transfer", err: ErrNotSwap.Error()},
+		{name: "truncated", err: "decoding input method:swapExactETHForTokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, ok := txs[tt.name]
+			require.True(t, ok)
+			msg := &client.EthTxPayload{}
+			msg.Event.Transaction = tx
+			swaps, err := Decode(msg)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.swaps, swaps)
+		})
+	}
+}
+
+func TestDecodePath(t *testing.T) {
+	_, _, err := DecodePath(common.FromHex("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"))
+	require.Error(t, err)
+	tokens, fees, err := DecodePath(common.FromHex("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB480001f4C02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"))
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{usdc, weth}, tokens)
+	require.Equal(t, []uint32{500}, fees)
+}
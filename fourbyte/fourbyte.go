@@ -0,0 +1,415 @@
+// Package fourbyte is an offline database mapping 4-byte function selectors
+// to signatures, used to decode calldata when no abi is known for the
+// contract called. A set of common signatures is embedded and more can be
+// imported from local dumps.
+package fourbyte
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+//go:embed signatures.txt
+var embedded []byte
+
+// ErrUnknownSelector is returned when decoding calldata with a selector missing from the database
+var ErrUnknownSelector = errors.New("unknown selector")
+
+// DB maps selectors to the signatures hashing to them. Several signatures
+// may share a selector, they are kept in the order they were added.
+type DB struct {
+	mx         sync.RWMutex
+	signatures map[[4]byte][]string
+}
+
+// New returns an empty database
+func New() *DB {
+	return &DB{signatures: make(map[[4]byte][]string)}
+}
+
+// Default returns a database holding the embedded signatures
+func Default() *DB {
+	db := New()
+	_, skipped, err := db.Import(bytes.NewReader(embedded))
+	if err != nil {
+		panic(err)
+	}
+	if skipped > 0 {
+		panic(errors.Errorf("invalid embedded signatures count:%v", skipped))
+	}
+	return db
+}
+
+// Selector returns the selector of a signature such as transfer(address,uint256)
+func Selector(signature string) [4]byte {
+	var selector [4]byte
+	copy(selector[:], crypto.Keccak256([]byte(signature)))
+	return selector
+}
+
+// Add validates signature and adds it to the database, returning false if it was already present
+func (db *DB) Add(signature string) (bool, error) {
+	signature = strings.Join(strings.Fields(signature), "")
+	if _, _, err := parseSignature(signature); err != nil {
+		return false, err
+	}
+	selector := Selector(signature)
+	db.mx.Lock()
+	defer db.mx.Unlock()
+	for _, s := range db.signatures[selector] {
+		if s == signature {
+			return false, nil
+		}
+	}
+	db.signatures[selector] = append(db.signatures[selector], signature)
+	return true, nil
+}
+
+// Lookup returns the signatures of selector
+func (db *DB) Lookup(selector [4]byte) []string {
+	db.mx.RLock()
+	defer db.mx.RUnlock()
+	return append([]string(nil), db.signatures[selector]...)
+}
+
+// Len returns the number of selectors in the database
+func (db *DB) Len() int {
+	db.mx.RLock()
+	defer db.mx.RUnlock()
+	return len(db.signatures)
+}
+
+// signatureEntry is an entry of the dumps of the 4byte.directory api
+type signatureEntry struct {
+	TextSignature string `json:"text_signature"`
+	HexSignature  string `json:"hex_signature"`
+}
+
+// Import adds the signatures of a dump, returning the number added and
+// the number of entries skipped because their signature is invalid or does
+// not hash to their selector. Dumps are either text with a signature,
+// optionally preceded by its selector, on every line, or json: a list of
+// 4byte.directory entries, a 4byte.directory api response or an object
+// mapping selectors to one or more signatures.
+func (db *DB) Import(r io.Reader) (added, skipped int, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && (data[0] == '[' || data[0] == '{') {
+		return db.importJSON(data)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var selector string
+		if strings.HasPrefix(text, "0x") && len(text) > 10 {
+			selector, text = text[:10], strings.TrimLeft(text[10:], " \t,:")
+		}
+		// signatures sharing a selector may be separated by semicolons
+		for _, signature := range strings.Split(text, ";") {
+			ok, err := db.add(selector, signature)
+			if err != nil {
+				skipped++
+			} else if ok {
+				added++
+			}
+		}
+	}
+	return added, skipped, scanner.Err()
+}
+
+func (db *DB) importJSON(data []byte) (added, skipped int, err error) {
+	var entries []signatureEntry
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return 0, 0, errors.Wrap(err, "decoding signatures")
+		}
+	} else {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return 0, 0, errors.Wrap(err, "decoding signatures")
+		}
+		if results, ok := raw["results"]; ok {
+			if err := json.Unmarshal(results, &entries); err != nil {
+				return 0, 0, errors.Wrap(err, "decoding signatures")
+			}
+		}
+		// selectors are sorted so that signatures sharing one are added in a stable order
+		selectors := make([]string, 0, len(raw))
+		for selector := range raw {
+			if selector != "results" && selector != "count" && selector != "next" && selector != "previous" {
+				selectors = append(selectors, selector)
+			}
+		}
+		sort.Strings(selectors)
+		for _, selector := range selectors {
+			value := raw[selector]
+			var signatures []string
+			if err := json.Unmarshal(value, &signatures); err != nil {
+				var signature string
+				if err := json.Unmarshal(value, &signature); err != nil {
+					skipped++
+					continue
+				}
+				signatures = []string{signature}
+			}
+			for _, signature := range signatures {
+				entries = append(entries, signatureEntry{TextSignature: signature, HexSignature: selector})
+			}
+		}
+	}
+	for _, entry := range entries {
+		ok, err := db.add(entry.HexSignature, entry.TextSignature)
+		if err != nil {
+			skipped++
+		} else if ok {
+			added++
+		}
+	}
+	return added, skipped, nil
+}
+
+// add adds signature checking that it hashes to selector when it is given
+func (db *DB) add(selector, signature string) (bool, error) {
+	signature = strings.Join(strings.Fields(signature), "")
+	if selector != "" {
+		if want := Selector(signature); !strings.EqualFold(selector, hexutil.Encode(want[:])) {
+			return false, errors.Errorf("selector:%v does not match signature:%v", selector, signature)
+		}
+	}
+	return db.Add(signature)
+}
+
+// ImportFile adds the signatures of the dump at path, see Import
+func (db *DB) ImportFile(path string) (added, skipped int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	added, skipped, err = db.Import(f)
+	return added, skipped, errors.Wrapf(err, "importing signatures path:%v", path)
+}
+
+// Call is calldata decoded with a signature from the database or guessed
+type Call struct {
+	Selector  string        `json:"selector"`
+	Signature string        `json:"signature"`
+	Method    string        `json:"method"`
+	Params    client.Params `json:"params"`
+	// Guessed is set when the argument types were guessed from the calldata rather than known
+	Guessed bool `json:"guessed,omitempty"`
+}
+
+// GuessedContractType is the contract type of contract calls whose
+// argument types were guessed
+const GuessedContractType = "guessed"
+
+// ContractCall returns the call as a contract call to the address to,
+// guessed calls have the contract type GuessedContractType
+func (c *Call) ContractCall(to string) *client.ContractCall {
+	call := &client.ContractCall{ContractAddress: to, MethodName: c.Method, Params: c.Params}
+	if c.Guessed {
+		call.ContractType = GuessedContractType
+	}
+	return call
+}
+
+// Decode decodes calldata with the signatures of its selector. When several
+// signatures share the selector the first one whose arguments re-encode to
+// the exact calldata is used, or else the first one able to decode it.
+func (db *DB) Decode(input []byte) (*Call, error) {
+	if len(input) < 4 {
+		return nil, errors.Errorf("invalid calldata length:%v", len(input))
+	}
+	var selector [4]byte
+	copy(selector[:], input)
+	signatures := db.Lookup(selector)
+	if len(signatures) == 0 {
+		return nil, ErrUnknownSelector
+	}
+	var fallback *Call
+	for _, signature := range signatures {
+		name, args, err := parseSignature(signature)
+		if err != nil {
+			continue
+		}
+		values, err := args.Unpack(input[4:])
+		if err != nil {
+			continue
+		}
+		params, err := client.UnpackParams(args, input[4:])
+		if err != nil {
+			continue
+		}
+		call := &Call{Selector: hexutil.Encode(selector[:]), Signature: signature, Method: name, Params: params}
+		if packed, err := args.Pack(values...); err == nil && bytes.Equal(packed, input[4:]) {
+			return call, nil
+		}
+		if fallback == nil {
+			fallback = call
+		}
+	}
+	if fallback == nil {
+		return nil, errors.Errorf("no signature of selector:%v matches calldata", hexutil.Encode(selector[:]))
+	}
+	return fallback, nil
+}
+
+// DecodeHex decodes 0x prefixed calldata, see Decode
+func (db *DB) DecodeHex(input string) (*Call, error) {
+	data, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid calldata")
+	}
+	return db.Decode(data)
+}
+
+// DecodeOrGuess decodes calldata with the signatures of its selector, or
+// guesses its argument types when the selector is unknown
+func (db *DB) DecodeOrGuess(input []byte) (*Call, error) {
+	call, err := db.Decode(input)
+	if errors.Is(err, ErrUnknownSelector) {
+		return Guess(input)
+	}
+	return call, err
+}
+
+// decodeOrGuessHex decodes 0x prefixed calldata, see DecodeOrGuess
+func (db *DB) decodeOrGuessHex(input string) (*Call, error) {
+	data, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid calldata")
+	}
+	return db.DecodeOrGuess(data)
+}
+
+// Handler sets the contract calls of transactions and internal transactions
+// the api did not decode before passing them to next. The argument types of
+// calls whose selector is not in the database are guessed.
+func (db *DB) Handler(next client.Handler) client.Handler {
+	return func(ctx context.Context, msg *client.EthTxPayload) error {
+		tx := &msg.Event.Transaction
+		if tx.ContractCall == nil {
+			if call, err := db.decodeOrGuessHex(tx.Input); err == nil {
+				tx.ContractCall = call.ContractCall(tx.To)
+			}
+		}
+		for i := range tx.InternalTransactions {
+			itx := &tx.InternalTransactions[i]
+			if itx.ContractCall.MethodName != "" {
+				continue
+			}
+			if call, err := db.decodeOrGuessHex(itx.Input); err == nil {
+				itx.ContractCall = *call.ContractCall(itx.To)
+			}
+		}
+		return next(ctx, msg)
+	}
+}
+
+// parseSignature returns the name and arguments of a signature, arguments
+// and tuple components are named arg0, arg1 and so on
+func parseSignature(signature string) (string, abi.Arguments, error) {
+	open := strings.IndexByte(signature, '(')
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return "", nil, errors.Errorf("invalid signature:%v", signature)
+	}
+	types, err := splitTypes(signature[open+1 : len(signature)-1])
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "invalid signature:%v", signature)
+	}
+	args := make(abi.Arguments, len(types))
+	for i, t := range types {
+		m, err := marshaling(argName(i), t)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "invalid signature:%v", signature)
+		}
+		if args[i].Type, err = abi.NewType(m.Type, "", m.Components); err != nil {
+			return "", nil, errors.Wrapf(err, "invalid signature:%v", signature)
+		}
+		args[i].Name = m.Name
+	}
+	return signature[:open], args, nil
+}
+
+func argName(i int) string {
+	return "arg" + strconv.Itoa(i)
+}
+
+// marshaling returns the abi description of the type t, such as
+// (address,uint256)[] for an array of tuples
+func marshaling(name, t string) (abi.ArgumentMarshaling, error) {
+	if !strings.HasPrefix(t, "(") {
+		return abi.ArgumentMarshaling{Name: name, Type: t}, nil
+	}
+	end := strings.LastIndexByte(t, ')')
+	fields, err := splitTypes(t[1:end])
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	components := make([]abi.ArgumentMarshaling, len(fields))
+	for i, field := range fields {
+		if components[i], err = marshaling(argName(i), field); err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+	}
+	return abi.ArgumentMarshaling{Name: name, Type: "tuple" + t[end+1:], Components: components}, nil
+}
+
+// splitTypes splits a comma separated list of types, leaving the commas within tuples
+func splitTypes(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var (
+		types []string
+		depth int
+		start int
+	)
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				types = append(types, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	types = append(types, s[start:])
+	for _, t := range types {
+		if t == "" {
+			return nil, errors.New("empty type")
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,173 @@
+package fourbyte
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// transfer(0x1111111111111111111111111111111111111111, 1000)
+const transferInput = "0xa9059cbb000000000000000000000000111111111111111111111111111111111111111100000000000000000000000000000000000000000000000000000000000003e8"
+
+func TestDecode(t *testing.T) {
+	db := Default()
+	call, err := db.DecodeHex(transferInput)
+	require.NoError(t, err)
+	require.Equal(t, "0xa9059cbb", call.Selector)
+	require.Equal(t, "transfer(address,uint256)", call.Signature)
+	require.Equal(t, "transfer", call.Method)
+	to, ok := call.Params.Address("arg0")
+	require.True(t, ok)
+	require.Equal(t, common.HexToAddress("0x1111111111111111111111111111111111111111"), to)
+	amount, ok := call.Params.BigInt("arg1")
+	require.True(t, ok)
+	require.Equal(t, int64(1000), amount.Int64())
+
+	// arrays of tuples
+	name, args, err := parseSignature("aggregate3((address,bool,bytes)[])")
+	require.NoError(t, err)
+	require.Equal(t, "aggregate3", name)
+	type call3 struct {
+		Arg0 common.Address
+		Arg1 bool
+		Arg2 []byte
+	}
+	data, err := args.Pack([]call3{{common.HexToAddress("0x2222222222222222222222222222222222222222"), true, []byte{0x18, 0x16, 0x0d, 0xdd}}})
+	require.NoError(t, err)
+	selector := Selector("aggregate3((address,bool,bytes)[])")
+	call, err = db.Decode(append(selector[:], data...))
+	require.NoError(t, err)
+	calls, ok := call.Params.Tuples("arg0")
+	require.True(t, ok)
+	require.Len(t, calls, 1)
+	inner, ok := calls[0].String("arg2")
+	require.True(t, ok)
+	require.Equal(t, "0x18160ddd", inner)
+
+	_, err = db.DecodeHex("0x12345678")
+	require.ErrorIs(t, err, ErrUnknownSelector)
+	_, err = db.DecodeHex("0xa9059cbb00")
+	require.ErrorContains(t, err, "no signature of selector:0xa9059cbb matches calldata")
+}
+
+func TestDecodeCollision(t *testing.T) {
+	db := New()
+	// many_msg_babbage(bytes1) shares the selector of transfer(address,uint256)
+	_, _, err := db.Import(strings.NewReader("many_msg_babbage(bytes1)\ntransfer(address,uint256)\n"))
+	require.NoError(t, err)
+	require.Len(t, db.Lookup(Selector("transfer(address,uint256)")), 2)
+	call, err := db.DecodeHex(transferInput)
+	require.NoError(t, err)
+	require.Equal(t, "transfer(address,uint256)", call.Signature)
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		dump    string
+		added   int
+		skipped int
+		err     string
+	}{
+		{name: "text", dump: "# comment\n0xa9059cbb transfer(address,uint256);many_msg_babbage(bytes1)\n0x095ea7b3,approve(address, uint256)\nsync()\n", added: 4},
+		{name: "entries", dump: `[{"text_signature": "transfer(address,uint256)", "hex_signature": "0xa9059cbb"}]`, added: 1},
+		{name: "results", dump: `{"count": 1, "next": null, "previous": null, "results": [{"text_signature": "sync()", "hex_signature": "0xfff6cae9"}]}`, added: 1},
+		{name: "map", dump: `{"0xa9059cbb": ["transfer(address,uint256)", "many_msg_babbage(bytes1)"], "0xfff6cae9": "sync()"}`, added: 3},
+		// bad entries are skipped rather than failing the whole dump
+		{name: "mismatch", dump: "0x12345678 transfer(address,uint256)\nsync()\n", added: 1, skipped: 1},
+		{name: "invalid", dump: "transfer(address,uint256\nsync()\n", added: 1, skipped: 1},
+		{name: "invalidEntries", dump: `[{"text_signature": "sync()", "hex_signature": "0x12345678"}, {"text_signature": "sync(", "hex_signature": ""}, {"text_signature": "sync()", "hex_signature": "0xfff6cae9"}]`, added: 1, skipped: 2},
+		{name: "invalidMap", dump: `{"0xa9059cbb": 1, "0xfff6cae9": "sync()"}`, added: 1, skipped: 1},
+		{name: "malformed", dump: `[{"text_signature": }]`, err: "decoding signatures"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, skipped, err := New().Import(strings.NewReader(tt.dump))
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.added, added)
+			require.Equal(t, tt.skipped, skipped)
+		})
+	}
+
+	db := Default()
+	added, _, err := db.Import(strings.NewReader("transfer(address,uint256)\n"))
+	require.NoError(t, err)
+	require.Zero(t, added)
+}
+
+func TestGuess(t *testing.T) {
+	input := "0x12345678" +
+		"0000000000000000000000001111111111111111111111111111111111111111" +
+		"00000000000000000000000000000000000000000000003635c9adc5dea00000" +
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+	call, err := Guess(hexutil.MustDecode(input))
+	require.NoError(t, err)
+	require.True(t, call.Guessed)
+	require.Equal(t, "0x12345678(address,uint256,bytes32)", call.Signature)
+	amount, ok := call.Params.BigInt("arg1")
+	require.True(t, ok)
+	require.Equal(t, "1000000000000000000000", amount.String())
+
+	_, err = Guess(hexutil.MustDecode("0x1234567800"))
+	require.Error(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	db := Default()
+	msg := &client.EthTxPayload{}
+	msg.Event.Transaction = client.TransactionPayload{
+		To:    "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+		Input: transferInput,
+		InternalTransactions: []client.InternalTransaction{
+			{To: "0x2222222222222222222222222222222222222222", Input: "0x18160ddd"},
+			{To: "0x3333333333333333333333333333333333333333", Input: "0x12345678"},
+		},
+	}
+	var got *client.EthTxPayload
+	handler := db.Handler(func(ctx context.Context, msg *client.EthTxPayload) error {
+		got = msg
+		return nil
+	})
+	require.NoError(t, handler(context.Background(), msg))
+	tx := got.Event.Transaction
+	require.Equal(t, "transfer", tx.ContractCall.MethodName)
+	require.Equal(t, "0xdAC17F958D2ee523a2206206994597C13D831ec7", tx.ContractCall.ContractAddress)
+	require.Equal(t, "totalSupply", tx.InternalTransactions[0].ContractCall.MethodName)
+	require.Empty(t, tx.ContractCall.ContractType)
+	// unknown selectors are guessed
+	require.Equal(t, "0x12345678", tx.InternalTransactions[1].ContractCall.MethodName)
+	require.Equal(t, GuessedContractType, tx.InternalTransactions[1].ContractCall.ContractType)
+}
+
+func TestHandlerGuess(t *testing.T) {
+	msg := &client.EthTxPayload{}
+	msg.Event.Transaction = client.TransactionPayload{
+		To: "0x3333333333333333333333333333333333333333",
+		Input: "0x12345678" +
+			"0000000000000000000000001111111111111111111111111111111111111111" +
+			"00000000000000000000000000000000000000000000003635c9adc5dea00000",
+	}
+	var got *client.EthTxPayload
+	handler := Default().Handler(func(ctx context.Context, msg *client.EthTxPayload) error {
+		got = msg
+		return nil
+	})
+	require.NoError(t, handler(context.Background(), msg))
+	call := got.Event.Transaction.ContractCall
+	require.NotNil(t, call)
+	require.Equal(t, "0x12345678", call.MethodName)
+	require.Equal(t, GuessedContractType, call.ContractType)
+	require.Equal(t, "0x1111111111111111111111111111111111111111", call.Params["arg0"])
+	amount, ok := call.Params.BigInt("arg1")
+	require.True(t, ok)
+	require.Equal(t, "1000000000000000000000", amount.String())
+}
@@ -0,0 +1,56 @@
+package fourbyte
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// Guess decodes calldata with an unknown selector by guessing the type of
+// every 32 byte word. Words with 12 leading zero bytes are addresses when
+// their value is too large to be an amount and uint256 otherwise, other words
+// are bytes32. Dynamic types are not detected and show up as their offsets
+// and lengths.
+func Guess(input []byte) (*Call, error) {
+	if len(input) < 4 || (len(input)-4)%32 != 0 {
+		return nil, errors.Errorf("calldata is not made of 32 byte words length:%v", len(input))
+	}
+	selector := hexutil.Encode(input[:4])
+	params := make(client.Params)
+	var types []string
+	for i := 0; 4+32*i < len(input); i++ {
+		word := input[4+32*i : 4+32*(i+1)]
+		name := argName(i)
+		switch {
+		case isZero(word[:12]) && !isZero(word[12:15]):
+			types = append(types, "address")
+			params[name] = common.BytesToAddress(word).Hex()
+		case isZero(word[:12]):
+			types = append(types, "uint256")
+			params[name] = new(big.Int).SetBytes(word).String()
+		default:
+			types = append(types, "bytes32")
+			params[name] = hexutil.Encode(word)
+		}
+	}
+	return &Call{
+		Selector:  selector,
+		Signature: selector + "(" + strings.Join(types, ",") + ")",
+		Method:    selector,
+		Params:    params,
+		Guessed:   true,
+	}, nil
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+// Package gas is a client for the blocknative gas platform rest api,
+// providing gas price and base fee predictions
+package gas
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/internal/rest"
+)
+
+const (
+	// DefaultBaseURL is the url of the blocknative rest api
+	DefaultBaseURL = rest.DefaultBaseURL
+	// DefaultCacheTTL is the time responses are cached for, the api updates its predictions about every second
+	DefaultCacheTTL = time.Second
+	// DefaultBackoff is the delay before the first retry of a request
+	DefaultBackoff = rest.DefaultBackoff
+)
+
+// Opts provides configuration over the gas platform client
+type Opts struct {
+	// APIKey is the blocknative api key, the same key used for the websocket
+	// api. BLOCKNATIVE_DAPP_ID is used if empty.
+	APIKey string
+	// BaseURL overrides DefaultBaseURL
+	BaseURL string
+	// MaxRetries is the number of times a failed request is retried
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubling on every attempt
+	Backoff time.Duration
+	// CacheTTL overrides DefaultCacheTTL, caching is disabled if negative
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+}
+
+// Client queries the gas platform api
+type Client struct {
+	opts  Opts
+	rest  *rest.Client
+	mx    sync.Mutex
+	cache map[string]cached
+}
+
+type cached struct {
+	body    []byte
+	expires time.Time
+}
+
+// APIError is returned for requests the api responded to with an error status
+type APIError = rest.APIError
+
+// NewClient returns a new gas platform client
+func NewClient(opts Opts) *Client {
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+	return &Client{
+		opts: opts,
+		rest: rest.NewClient(rest.Opts{
+			API:        "gas",
+			APIKey:     opts.APIKey,
+			BaseURL:    opts.BaseURL,
+			MaxRetries: opts.MaxRetries,
+			Backoff:    opts.Backoff,
+			HTTPClient: opts.HTTPClient,
+		}),
+		cache: make(map[string]cached),
+	}
+}
+
+// BlockPricesOpts selects the predictions returned by BlockPrices
+type BlockPricesOpts struct {
+	// ChainID of the network, the api defaults to ethereum mainnet if 0
+	ChainID int64
+	// ConfidenceLevels are the percentages of confidence prices are
+	// predicted for, the api defaults to 70, 80, 90, 95 and 99 if empty
+	ConfidenceLevels []int
+}
+
+// BlockPrices returns the gas prices predicted for inclusion in the next block
+func (c *Client) BlockPrices(ctx context.Context, opts BlockPricesOpts) (*BlockPrices, error) {
+	query := url.Values{}
+	if opts.ChainID != 0 {
+		query.Set("chainid", strconv.FormatInt(opts.ChainID, 10))
+	}
+	if len(opts.ConfidenceLevels) > 0 {
+		levels := make([]string, len(opts.ConfidenceLevels))
+		for i, level := range opts.ConfidenceLevels {
+			levels[i] = strconv.Itoa(level)
+		}
+		query.Set("confidenceLevels", strings.Join(levels, ","))
+	}
+	var out BlockPrices
+	if err := c.get(ctx, "/gasprices/blockprices", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// BaseFeeEstimates returns the base fees predicted for the next blocks on ethereum mainnet
+func (c *Client) BaseFeeEstimates(ctx context.Context) (*BaseFeeEstimates, error) {
+	var out BaseFeeEstimates
+	if err := c.get(ctx, "/gasprices/basefee-estimates", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Chains returns the chains supported by the gas platform
+func (c *Client) Chains(ctx context.Context) ([]Chain, error) {
+	var out []Chain
+	if err := c.get(ctx, "/chains", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// get decodes the json response of the api path into out, using the cached response if it is fresh
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	body, ok := c.cached(u)
+	if !ok {
+		var err error
+		if body, err = c.rest.Do(ctx, http.MethodGet, u, nil, true); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return errors.Wrapf(err, "decoding response path:%v", path)
+	}
+	if !ok && c.opts.CacheTTL > 0 {
+		c.mx.Lock()
+		c.cache[u] = cached{body: body, expires: time.Now().Add(c.opts.CacheTTL)}
+		c.mx.Unlock()
+	}
+	return nil
+}
+
+func (c *Client) cached(u string) ([]byte, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	entry, ok := c.cache[u]
+	if !ok || time.Now().After(entry.expires) {
+		delete(c.cache, u)
+		return nil, false
+	}
+	return entry.body, true
+}
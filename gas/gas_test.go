@@ -0,0 +1,135 @@
+package gas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const blockPricesResponse = `{
+	"system": "ethereum", "network": "main", "unit": "gwei", "maxPrice": 45,
+	"currentBlockNumber": 17000000, "msSinceLastBlock": 3200,
+	"blockPrices": [{
+		"blockNumber": 17000001, "estimatedTransactionCount": 150, "baseFeePerGas": 30.5,
+		"estimatedPrices": [
+			{"confidence": 99, "price": 33, "maxPriorityFeePerGas": 2.1, "maxFeePerGas": 63.1},
+			{"confidence": 90, "price": 32, "maxPriorityFeePerGas": 1.2, "maxFeePerGas": 62.2}
+		]
+	}]
+}`
+
+const baseFeeResponse = `{
+	"system": "ethereum", "network": "main", "unit": "gwei", "blockNumber": 17000000, "baseFeePerGas": 30.5,
+	"estimatedBaseFees": [
+		{"pending+2": [{"confidence": 99, "baseFee": 34.3}]},
+		{"pending+1": [{"confidence": 99, "baseFee": 31.1}, {"confidence": 50, "baseFee": 30.6}]}
+	]
+}`
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.Header.Get("Authorization"))
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(Opts{APIKey: "key", BaseURL: srv.URL, Backoff: time.Millisecond, MaxRetries: 2})
+}
+
+func TestBlockPrices(t *testing.T) {
+	var calls int32
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/gasprices/blockprices", r.URL.Path)
+		require.Equal(t, "137", r.URL.Query().Get("chainid"))
+		require.Equal(t, "90,99", r.URL.Query().Get("confidenceLevels"))
+		// fail the first attempt to exercise retries
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(blockPricesResponse))
+	})
+	opts := BlockPricesOpts{ChainID: 137, ConfidenceLevels: []int{90, 99}}
+	prices, err := c.BlockPrices(context.Background(), opts)
+	require.NoError(t, err)
+	require.Equal(t, uint64(17000000), prices.CurrentBlockNumber)
+	require.Equal(t, 30.5, prices.BlockPrices[0].BaseFeePerGas)
+	price, ok := prices.Price(90)
+	require.True(t, ok)
+	require.Equal(t, EstimatedPrice{Confidence: 90, Price: 32, MaxPriorityFeePerGas: 1.2, MaxFeePerGas: 62.2}, price)
+	_, ok = prices.Price(70)
+	require.False(t, ok)
+
+	// the response is cached
+	_, err = c.BlockPrices(context.Background(), opts)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestBaseFeeEstimates(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/gasprices/basefee-estimates", r.URL.Path)
+		w.Write([]byte(baseFeeResponse))
+	})
+	estimates, err := c.BaseFeeEstimates(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 30.5, estimates.BaseFeePerGas)
+	require.Equal(t, []PendingBaseFee{
+		{Blocks: 1, Estimates: []BaseFeeEstimate{{99, 31.1}, {50, 30.6}}},
+		{Blocks: 2, Estimates: []BaseFeeEstimate{{99, 34.3}}},
+	}, estimates.EstimatedBaseFees)
+}
+
+func TestChains(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/chains", r.URL.Path)
+		w.Write([]byte(`[{"chainId": 1, "system": "ethereum", "network": "main", "label": "Ethereum", "bpSupport": true, "mpSupport": true}]`))
+	})
+	chains, err := c.Chains(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Chain{{ChainID: 1, System: "ethereum", Network: "main", Label: "Ethereum", BlockPrices: true, Mempool: true}}, chains)
+}
+
+func TestErrors(t *testing.T) {
+	var calls int32
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/chains" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"msg": "invalid api key"}`))
+	})
+	_, err := c.BaseFeeEstimates(context.Background())
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	require.Equal(t, "invalid api key", apiErr.Message)
+	// client errors are not retried
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	_, err = c.Chains(context.Background())
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	require.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestCacheDisabled(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	c := NewClient(Opts{BaseURL: srv.URL, CacheTTL: -1})
+	for i := 0; i < 2; i++ {
+		_, err := c.Chains(context.Background())
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
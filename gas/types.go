@@ -0,0 +1,108 @@
+package gas
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BlockPrices is the response of the block prices endpoint, prices are in Unit (gwei)
+type BlockPrices struct {
+	System             string       `json:"system"`
+	Network            string       `json:"network"`
+	Unit               string       `json:"unit"`
+	MaxPrice           float64      `json:"maxPrice"`
+	CurrentBlockNumber uint64       `json:"currentBlockNumber"`
+	MsSinceLastBlock   int64        `json:"msSinceLastBlock"`
+	BlockPrices        []BlockPrice `json:"blockPrices"`
+}
+
+// BlockPrice holds the predictions for a block
+type BlockPrice struct {
+	BlockNumber               uint64           `json:"blockNumber"`
+	EstimatedTransactionCount int              `json:"estimatedTransactionCount"`
+	BaseFeePerGas             float64          `json:"baseFeePerGas"`
+	EstimatedPrices           []EstimatedPrice `json:"estimatedPrices"`
+}
+
+// EstimatedPrice is the price predicted to be included in the block with a confidence percentage
+type EstimatedPrice struct {
+	Confidence           int     `json:"confidence"`
+	Price                float64 `json:"price"`
+	MaxPriorityFeePerGas float64 `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         float64 `json:"maxFeePerGas"`
+}
+
+// Price returns the estimated price of the next block with the confidence
+func (p *BlockPrices) Price(confidence int) (EstimatedPrice, bool) {
+	if len(p.BlockPrices) == 0 {
+		return EstimatedPrice{}, false
+	}
+	for _, price := range p.BlockPrices[0].EstimatedPrices {
+		if price.Confidence == confidence {
+			return price, true
+		}
+	}
+	return EstimatedPrice{}, false
+}
+
+// BaseFeeEstimates is the response of the base fee estimates endpoint, fees are in Unit (gwei)
+type BaseFeeEstimates struct {
+	System            string           `json:"system"`
+	Network           string           `json:"network"`
+	Unit              string           `json:"unit"`
+	BlockNumber       uint64           `json:"blockNumber"`
+	BaseFeePerGas     float64          `json:"baseFeePerGas"`
+	EstimatedBaseFees []PendingBaseFee `json:"estimatedBaseFees"`
+}
+
+// PendingBaseFee holds the base fees predicted for the block Blocks after the pending one
+type PendingBaseFee struct {
+	Blocks    int               `json:"blocks"`
+	Estimates []BaseFeeEstimate `json:"estimates"`
+}
+
+// BaseFeeEstimate is a base fee predicted with a confidence percentage
+type BaseFeeEstimate struct {
+	Confidence int     `json:"confidence"`
+	BaseFee    float64 `json:"baseFee"`
+}
+
+// UnmarshalJSON decodes the estimates keyed by "pending+<blocks>" into EstimatedBaseFees
+func (e *BaseFeeEstimates) UnmarshalJSON(data []byte) error {
+	type plain BaseFeeEstimates
+	var raw struct {
+		plain
+		EstimatedBaseFees []map[string][]BaseFeeEstimate `json:"estimatedBaseFees"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = BaseFeeEstimates(raw.plain)
+	for _, entry := range raw.EstimatedBaseFees {
+		for key, estimates := range entry {
+			blocks, err := strconv.Atoi(strings.TrimPrefix(key, "pending+"))
+			if err != nil {
+				return errors.Errorf("unexpected base fee estimate key:%v", key)
+			}
+			e.EstimatedBaseFees = append(e.EstimatedBaseFees, PendingBaseFee{Blocks: blocks, Estimates: estimates})
+		}
+	}
+	sort.Slice(e.EstimatedBaseFees, func(i, j int) bool { return e.EstimatedBaseFees[i].Blocks < e.EstimatedBaseFees[j].Blocks })
+	return nil
+}
+
+// Chain is a chain supported by the gas platform
+type Chain struct {
+	ChainID int64  `json:"chainId"`
+	System  string `json:"system"`
+	Network string `json:"network"`
+	Label   string `json:"label"`
+	// BlockPrices reports support by the block prices endpoint
+	BlockPrices bool `json:"bpSupport"`
+	// Mempool reports support by the mempool websocket api
+	Mempool bool `json:"mpSupport"`
+}
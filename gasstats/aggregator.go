@@ -0,0 +1,209 @@
+package gasstats
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+const (
+	// DefaultWindow is the period samples are retained for when none is given
+	DefaultWindow = time.Hour
+	// DefaultMaxSamples bounds the number of samples retained when no limit is given
+	DefaultMaxSamples = 100000
+)
+
+// Opts provides configuration over an aggregator
+type Opts struct {
+	// Window is the longest period statistics can be computed over
+	Window time.Duration
+	// MaxSamples bounds the number of transactions retained, the oldest are dropped first
+	MaxSamples int
+	// Now returns the current time, time.Now if nil
+	Now func() time.Time
+}
+
+// Percentiles summarizes the distribution of a metric
+type Percentiles struct {
+	P10   float64 `json:"p10"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	Count int     `json:"count"`
+}
+
+// Stats holds the percentiles of every metric with at least one sample
+type Stats map[Metric]Percentiles
+
+// sample holds the fees of a transaction, merged across its events
+type sample struct {
+	at   time.Time
+	hash string
+	to   string
+	fees Fees
+}
+
+// Aggregator maintains rolling fee statistics over the transactions it is
+// given. Every transaction is counted once, later events such as a
+// confirmation adding the metrics they carry to the first one observed.
+type Aggregator struct {
+	opts    Opts
+	mx      sync.Mutex
+	samples []*sample
+	byHash  map[string]*sample
+}
+
+// NewAggregator returns a new aggregator
+func NewAggregator(opts Opts) *Aggregator {
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.MaxSamples <= 0 {
+		opts.MaxSamples = DefaultMaxSamples
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Aggregator{opts: opts, byHash: make(map[string]*sample)}
+}
+
+// Write adds the fees of the transaction in msg, it has the signature of
+// client.Handler. Events whose fees cannot be parsed are ignored.
+func (a *Aggregator) Write(_ context.Context, msg *client.EthTxPayload) error {
+	fees, err := ParseFees(msg)
+	if err != nil {
+		return nil
+	}
+	tx := msg.Event.Transaction
+	hash := strings.ToLower(tx.Hash)
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	now := a.opts.Now()
+	a.prune(now)
+	if s, ok := a.byHash[hash]; ok && hash != "" {
+		for m, v := range fees {
+			s.fees[m] = v
+		}
+		return nil
+	}
+	s := &sample{at: now, hash: hash, to: strings.ToLower(tx.To), fees: fees}
+	a.samples = append(a.samples, s)
+	if hash != "" {
+		a.byHash[hash] = s
+	}
+	if len(a.samples) > a.opts.MaxSamples {
+		a.drop(len(a.samples) - a.opts.MaxSamples)
+	}
+	return nil
+}
+
+// prune drops the samples older than the window
+func (a *Aggregator) prune(now time.Time) {
+	cutoff := now.Add(-a.opts.Window)
+	n := sort.Search(len(a.samples), func(i int) bool { return a.samples[i].at.After(cutoff) })
+	a.drop(n)
+}
+
+// drop removes the n oldest samples
+func (a *Aggregator) drop(n int) {
+	for _, s := range a.samples[:n] {
+		delete(a.byHash, s.hash)
+	}
+	a.samples = append(a.samples[:0], a.samples[n:]...)
+}
+
+// Stats returns the statistics of the transactions observed within window
+func (a *Aggregator) Stats(window time.Duration) Stats {
+	return a.stats(window, func(*sample) bool { return true })
+}
+
+// ContractStats returns the statistics of the transactions sent to the address to within window
+func (a *Aggregator) ContractStats(window time.Duration, to string) Stats {
+	to = strings.ToLower(to)
+	return a.stats(window, func(s *sample) bool { return s.to == to })
+}
+
+// Contracts returns the addresses transactions observed within window were
+// sent to, along with the number of transactions, busiest first
+func (a *Aggregator) Contracts(window time.Duration) []ContractCount {
+	counts := make(map[string]int)
+	a.each(window, func(s *sample) {
+		if s.to != "" {
+			counts[s.to]++
+		}
+	})
+	out := make([]ContractCount, 0, len(counts))
+	for to, n := range counts {
+		out = append(out, ContractCount{Address: to, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Address < out[j].Address
+	})
+	return out
+}
+
+// ContractCount is the number of transactions sent to an address
+type ContractCount struct {
+	Address string `json:"address"`
+	Count   int    `json:"count"`
+}
+
+func (a *Aggregator) stats(window time.Duration, match func(*sample) bool) Stats {
+	values := make(map[Metric][]float64)
+	a.each(window, func(s *sample) {
+		if !match(s) {
+			return
+		}
+		for m, v := range s.fees {
+			values[m] = append(values[m], v)
+		}
+	})
+	stats := make(Stats, len(values))
+	for m, vs := range values {
+		sort.Float64s(vs)
+		stats[m] = Percentiles{
+			P10:   Percentile(vs, 10),
+			P50:   Percentile(vs, 50),
+			P90:   Percentile(vs, 90),
+			Count: len(vs),
+		}
+	}
+	return stats
+}
+
+// each calls fn with the samples observed within window
+func (a *Aggregator) each(window time.Duration, fn func(*sample)) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	now := a.opts.Now()
+	a.prune(now)
+	cutoff := now.Add(-window)
+	for i := len(a.samples) - 1; i >= 0 && a.samples[i].at.After(cutoff); i-- {
+		fn(a.samples[i])
+	}
+}
+
+// Percentile returns the p-th percentile of sorted values, interpolating
+// linearly between the closest ranks
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo < 0 {
+		return sorted[0]
+	}
+	if hi >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
@@ -0,0 +1,191 @@
+package gasstats
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+const (
+	// DefaultBlockTime is used to convert the time a transaction was pending
+	// into blocks when the api does not report the number of blocks
+	DefaultBlockTime = 12 * time.Second
+	// DefaultMinSamples is the number of confirmations needed before estimates are made
+	DefaultMinSamples = 10
+	// maxBaseFeeIncrease is the factor the base fee can increase by from one block to the next
+	maxBaseFeeIncrease = 1.125
+)
+
+// ErrNotEnoughData is returned by Estimate when too few confirmations were observed
+var ErrNotEnoughData = errors.New("not enough confirmed transactions observed")
+
+// EstimatorOpts provides configuration over an estimator
+type EstimatorOpts struct {
+	Opts
+	// BlockTime is the expected time between blocks
+	BlockTime time.Duration
+	// MinSamples is the number of confirmations needed before estimates are made
+	MinSamples int
+}
+
+// Estimate is the fee recommended for a transaction, in gwei
+type Estimate struct {
+	Confidence float64 `json:"confidence"`
+	Blocks     int     `json:"blocks"`
+	// PriorityFee is the max priority fee, or the gas price above the base fee of legacy transactions
+	PriorityFee float64 `json:"priorityFee"`
+	// MaxFee covers the priority fee on top of the largest base fee possible within Blocks
+	MaxFee float64 `json:"maxFee"`
+	// BaseFee is the base fee of the latest confirmation
+	BaseFee float64 `json:"baseFee"`
+	// Samples is the number of confirmations the estimate is based on
+	Samples int `json:"samples"`
+}
+
+// confirmation records the priority fee paid by a confirmed transaction and how long it was pending
+type confirmation struct {
+	at          time.Time
+	priorityFee float64
+	blocks      int
+}
+
+// GasEstimator recommends fees by correlating the priority fee paid by
+// confirmed transactions with the number of blocks they were pending for
+type GasEstimator struct {
+	opts          EstimatorOpts
+	mx            sync.Mutex
+	confirmations []confirmation
+	baseFee       float64
+}
+
+// NewGasEstimator returns a new estimator
+func NewGasEstimator(opts EstimatorOpts) *GasEstimator {
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.MaxSamples <= 0 {
+		opts.MaxSamples = DefaultMaxSamples
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.BlockTime <= 0 {
+		opts.BlockTime = DefaultBlockTime
+	}
+	if opts.MinSamples <= 0 {
+		opts.MinSamples = DefaultMinSamples
+	}
+	return &GasEstimator{opts: opts}
+}
+
+// Write records the fee paid by the transaction in msg if it is confirmed,
+// it has the signature of client.Handler. Other events are ignored.
+func (e *GasEstimator) Write(_ context.Context, msg *client.EthTxPayload) error {
+	tx := msg.Event.Transaction
+	if tx.Status != "confirmed" {
+		return nil
+	}
+	fees, err := ParseFees(msg)
+	if err != nil {
+		return nil
+	}
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if baseFee, ok := fees[MetricBaseFee]; ok {
+		e.baseFee = baseFee
+	}
+	tip, ok := fees[MetricPriorityFee]
+	if !ok {
+		return nil
+	}
+	now := e.opts.Now()
+	e.prune(now)
+	blocks, ok := e.blocksPending(msg)
+	if !ok {
+		return nil
+	}
+	e.confirmations = append(e.confirmations, confirmation{at: now, priorityFee: tip, blocks: blocks})
+	if n := len(e.confirmations) - e.opts.MaxSamples; n > 0 {
+		e.confirmations = append(e.confirmations[:0], e.confirmations[n:]...)
+	}
+	return nil
+}
+
+// blocksPending returns the number of blocks the transaction was pending
+// for, derived from the time it was pending if the api did not report it.
+// It returns false when neither is known.
+func (e *GasEstimator) blocksPending(msg *client.EthTxPayload) (int, bool) {
+	tx := msg.Event.Transaction
+	if tx.BlocksPending > 0 {
+		return int(tx.BlocksPending), true
+	}
+	ms, err := strconv.ParseInt(tx.TimePending, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	return int(time.Duration(ms) * time.Millisecond / e.opts.BlockTime), true
+}
+
+func (e *GasEstimator) prune(now time.Time) {
+	cutoff := now.Add(-e.opts.Window)
+	n := sort.Search(len(e.confirmations), func(i int) bool { return e.confirmations[i].at.After(cutoff) })
+	e.confirmations = append(e.confirmations[:0], e.confirmations[n:]...)
+}
+
+// Estimate returns the lowest priority fee at which at least confidence
+// percent of the observed transactions paying as much were included within
+// the given number of blocks. A transaction pending for fewer than blocks
+// blocks counts as included within them.
+func (e *GasEstimator) Estimate(confidence float64, blocks int) (Estimate, error) {
+	if confidence <= 0 || confidence > 100 {
+		return Estimate{}, errors.Errorf("confidence must be a percentage:%v", confidence)
+	}
+	if blocks < 1 {
+		return Estimate{}, errors.Errorf("blocks must be at least 1:%v", blocks)
+	}
+	e.mx.Lock()
+	e.prune(e.opts.Now())
+	confirmations := append([]confirmation(nil), e.confirmations...)
+	baseFee := e.baseFee
+	e.mx.Unlock()
+	if len(confirmations) < e.opts.MinSamples {
+		return Estimate{}, ErrNotEnoughData
+	}
+
+	// walk down from the highest fee, tracking the share of transactions
+	// paying at least the current fee that were included in time
+	sort.Slice(confirmations, func(i, j int) bool { return confirmations[i].priorityFee > confirmations[j].priorityFee })
+	var (
+		included int
+		fee      = math.NaN()
+	)
+	for i, c := range confirmations {
+		if c.blocks < blocks {
+			included++
+		}
+		// only consider thresholds between distinct fees
+		if i+1 < len(confirmations) && confirmations[i+1].priorityFee == c.priorityFee {
+			continue
+		}
+		if float64(included)/float64(i+1)*100 >= confidence {
+			fee = c.priorityFee
+		}
+	}
+	if math.IsNaN(fee) {
+		return Estimate{}, errors.Errorf("no fee reached confidence:%v blocks:%v", confidence, blocks)
+	}
+	return Estimate{
+		Confidence:  confidence,
+		Blocks:      blocks,
+		PriorityFee: fee,
+		MaxFee:      baseFee*math.Pow(maxBaseFeeIncrease, float64(blocks)) + fee,
+		BaseFee:     baseFee,
+		Samples:     len(confirmations),
+	}, nil
+}
@@ -0,0 +1,98 @@
+// Package gasstats aggregates the fees paid by transactions received from
+// the blocknative api into statistics that transactions can be priced from
+package gasstats
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// Metric names a fee tracked by the aggregator
+type Metric string
+
+// Tracked metrics, all in gwei
+const (
+	// MetricPriorityFee is the max priority fee of dynamic fee transactions,
+	// or the gas price above the base fee of legacy ones
+	MetricPriorityFee Metric = "priorityFee"
+	// MetricMaxFee is the max fee of dynamic fee transactions or the gas price of legacy ones
+	MetricMaxFee Metric = "maxFee"
+	// MetricEffectiveGasPrice is the price per gas actually paid, known once the base fee is
+	MetricEffectiveGasPrice Metric = "effectiveGasPrice"
+	// MetricBaseFee is the base fee of the block a transaction was included in
+	MetricBaseFee Metric = "baseFee"
+)
+
+// Metrics lists the tracked metrics
+var Metrics = []Metric{MetricPriorityFee, MetricMaxFee, MetricEffectiveGasPrice, MetricBaseFee}
+
+// Fees holds the metrics known for a transaction
+type Fees map[Metric]float64
+
+// ParseFees returns the fees of the transaction in msg in gwei. Dynamic fee
+// (type 2) transactions are priced by their max fee and max priority fee,
+// legacy (type 0) and access list (type 1) transactions by their gas price.
+// The effective gas price and the priority fee of legacy transactions are
+// only known when the event carries the base fee.
+func ParseFees(msg *client.EthTxPayload) (Fees, error) {
+	tx := msg.Event.Transaction
+	fees := make(Fees)
+	baseFee, hasBaseFee, err := parseGwei(tx.BaseFeePerGas)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing base fee:%v", tx.BaseFeePerGas)
+	}
+	if hasBaseFee {
+		fees[MetricBaseFee] = baseFee
+	}
+	if tx.Type == types.DynamicFeeTxType || (tx.MaxFeePerGas != "" && tx.GasPrice == "") {
+		maxFee, ok, err := parseGwei(tx.MaxFeePerGas)
+		if err != nil || !ok {
+			return nil, errors.Errorf("parsing max fee:%v", tx.MaxFeePerGas)
+		}
+		tip, ok, err := parseGwei(tx.MaxPriorityFeePerGas)
+		if err != nil || !ok {
+			return nil, errors.Errorf("parsing max priority fee:%v", tx.MaxPriorityFeePerGas)
+		}
+		fees[MetricMaxFee] = maxFee
+		fees[MetricPriorityFee] = tip
+		if hasBaseFee {
+			fees[MetricEffectiveGasPrice] = min(maxFee, baseFee+tip)
+		}
+		return fees, nil
+	}
+	price, ok, err := parseGwei(tx.GasPrice)
+	if err != nil || !ok {
+		return nil, errors.Errorf("parsing gas price:%v", tx.GasPrice)
+	}
+	fees[MetricMaxFee] = price
+	fees[MetricEffectiveGasPrice] = price
+	if hasBaseFee && price >= baseFee {
+		fees[MetricPriorityFee] = price - baseFee
+	}
+	return fees, nil
+}
+
+// parseGwei converts a decimal or hex wei amount to gwei, reporting
+// whether it was set
+func parseGwei(wei string) (float64, bool, error) {
+	if wei == "" {
+		return 0, false, nil
+	}
+	v, ok := client.ParseAmount(wei)
+	if !ok {
+		return 0, false, errors.Errorf("invalid amount:%v", wei)
+	}
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(v), big.NewFloat(params.GWei)).Float64()
+	return gwei, true, nil
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,192 @@
+package gasstats
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+func dynamicTx(hash, to string, maxFee, tip int64) *client.EthTxPayload {
+	msg := &client.EthTxPayload{}
+	msg.Event.Transaction.Type = 2
+	msg.Event.Transaction.Hash = hash
+	msg.Event.Transaction.To = to
+	msg.Event.Transaction.MaxFeePerGas = fmt.Sprint(maxFee * 1e9)
+	msg.Event.Transaction.MaxPriorityFeePerGas = fmt.Sprint(tip * 1e9)
+	return msg
+}
+
+func legacyTx(hash, to string, txType uint64, price int64) *client.EthTxPayload {
+	msg := &client.EthTxPayload{}
+	msg.Event.Transaction.Type = txType
+	msg.Event.Transaction.Hash = hash
+	msg.Event.Transaction.To = to
+	msg.Event.Transaction.GasPrice = fmt.Sprint(price * 1e9)
+	return msg
+}
+
+func TestParseFees(t *testing.T) {
+	msg := dynamicTx("0x01", "", 100, 2)
+	fees, err := ParseFees(msg)
+	require.NoError(t, err)
+	require.Equal(t, Fees{MetricMaxFee: 100, MetricPriorityFee: 2}, fees)
+
+	// the effective price is capped by the max fee
+	msg.Event.Transaction.BaseFeePerGas = "30000000000"
+	fees, err = ParseFees(msg)
+	require.NoError(t, err)
+	require.Equal(t, Fees{MetricMaxFee: 100, MetricPriorityFee: 2, MetricBaseFee: 30, MetricEffectiveGasPrice: 32}, fees)
+	msg.Event.Transaction.BaseFeePerGas = "99000000000"
+	fees, err = ParseFees(msg)
+	require.NoError(t, err)
+	require.Equal(t, float64(100), fees[MetricEffectiveGasPrice])
+
+	for _, txType := range []uint64{0, 1} {
+		msg = legacyTx("0x02", "", txType, 40)
+		fees, err = ParseFees(msg)
+		require.NoError(t, err)
+		require.Equal(t, Fees{MetricMaxFee: 40, MetricEffectiveGasPrice: 40}, fees)
+
+		msg.Event.Transaction.BaseFeePerGas = "30000000000"
+		fees, err = ParseFees(msg)
+		require.NoError(t, err)
+		require.Equal(t, Fees{MetricMaxFee: 40, MetricEffectiveGasPrice: 40, MetricBaseFee: 30, MetricPriorityFee: 10}, fees)
+	}
+
+	// amounts may be hex encoded
+	msg = dynamicTx("0x04", "", 100, 2)
+	msg.Event.Transaction.BaseFeePerGas = "0x6fc23ac00"
+	msg.Event.Transaction.MaxPriorityFeePerGas = "0x77359400"
+	fees, err = ParseFees(msg)
+	require.NoError(t, err)
+	require.Equal(t, Fees{MetricMaxFee: 100, MetricPriorityFee: 2, MetricBaseFee: 30, MetricEffectiveGasPrice: 32}, fees)
+
+	_, err = ParseFees(&client.EthTxPayload{})
+	require.Error(t, err)
+	msg = dynamicTx("0x03", "", 1, 1)
+	msg.Event.Transaction.MaxPriorityFeePerGas = ""
+	_, err = ParseFees(msg)
+	require.Error(t, err)
+}
+
+func TestAggregator(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	agg := NewAggregator(Opts{Window: 10 * time.Minute, Now: func() time.Time { return now }})
+	ctx := context.Background()
+
+	// tips of 1..10 gwei to the router, a legacy transaction elsewhere
+	for i := int64(1); i <= 10; i++ {
+		require.NoError(t, agg.Write(ctx, dynamicTx(fmt.Sprintf("0x%02d", i), "0xRouter", 100, i)))
+	}
+	require.NoError(t, agg.Write(ctx, legacyTx("0xff", "0xother", 0, 50)))
+	// unparseable events are ignored
+	require.NoError(t, agg.Write(ctx, &client.EthTxPayload{}))
+
+	stats := agg.ContractStats(time.Minute, "0xrouter")
+	require.Equal(t, Percentiles{P10: 1.9, P50: 5.5, P90: 9.1, Count: 10}, roundAll(stats[MetricPriorityFee]))
+	require.Equal(t, 10, stats[MetricMaxFee].Count)
+	_, ok := stats[MetricEffectiveGasPrice]
+	require.False(t, ok)
+
+	stats = agg.Stats(time.Minute)
+	require.Equal(t, 11, stats[MetricMaxFee].Count)
+	require.Equal(t, 10, stats[MetricPriorityFee].Count)
+	require.Equal(t, []ContractCount{{"0xrouter", 10}, {"0xother", 1}}, agg.Contracts(time.Minute))
+
+	// a confirmation adds the base fee to the transaction without counting it twice
+	confirmed := dynamicTx("0x01", "0xRouter", 100, 1)
+	confirmed.Event.Transaction.BaseFeePerGas = "20000000000"
+	require.NoError(t, agg.Write(ctx, confirmed))
+	stats = agg.Stats(time.Minute)
+	require.Equal(t, 11, stats[MetricMaxFee].Count)
+	// alongside the legacy transaction, which paid its gas price
+	require.Equal(t, Percentiles{P10: 23.9, P50: 35.5, P90: 47.1, Count: 2}, roundAll(stats[MetricEffectiveGasPrice]))
+
+	// samples leave shorter windows first and are dropped after the aggregator window
+	now = now.Add(5 * time.Minute)
+	require.NoError(t, agg.Write(ctx, legacyTx("0xee", "0xother", 1, 60)))
+	require.Equal(t, 1, agg.Stats(time.Minute)[MetricMaxFee].Count)
+	require.Equal(t, 12, agg.Stats(10 * time.Minute)[MetricMaxFee].Count)
+	now = now.Add(6 * time.Minute)
+	require.Equal(t, 1, agg.Stats(10 * time.Minute)[MetricMaxFee].Count)
+	require.Empty(t, agg.ContractStats(10*time.Minute, "0xrouter"))
+}
+
+func TestMaxSamples(t *testing.T) {
+	agg := NewAggregator(Opts{MaxSamples: 3})
+	for i := int64(1); i <= 5; i++ {
+		require.NoError(t, agg.Write(context.Background(), legacyTx(fmt.Sprint(i), "", 0, i)))
+	}
+	stats := agg.Stats(time.Minute)
+	require.Equal(t, Percentiles{P10: 3.2, P50: 4, P90: 4.8, Count: 3}, roundAll(stats[MetricMaxFee]))
+}
+
+func roundAll(p Percentiles) Percentiles {
+	round := func(f float64) float64 { return math.Round(f*1000) / 1000 }
+	return Percentiles{P10: round(p.P10), P50: round(p.P50), P90: round(p.P90), Count: p.Count}
+}
+
+func TestGasEstimator(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	est := NewGasEstimator(EstimatorOpts{Opts: Opts{Window: time.Hour, Now: func() time.Time { return now }}})
+	ctx := context.Background()
+
+	_, err := est.Estimate(90, 1)
+	require.ErrorIs(t, err, ErrNotEnoughData)
+
+	// transactions tipping 10 gwei or more are included in the next block,
+	// the rest are pending for 3 blocks
+	for i := int64(1); i <= 20; i++ {
+		msg := dynamicTx(fmt.Sprint(i), "", 200, i)
+		msg.Event.Transaction.Status = "confirmed"
+		msg.Event.Transaction.BaseFeePerGas = "30000000000"
+		if i < 10 {
+			msg.Event.Transaction.BlocksPending = 3
+		} else {
+			msg.Event.Transaction.TimePending = "6000"
+		}
+		require.NoError(t, est.Write(ctx, msg))
+	}
+	// confirmations without the time they were pending are skipped rather
+	// than counted as included in the next block
+	unknown := dynamicTx("0xu", "", 200, 1)
+	unknown.Event.Transaction.Status = "confirmed"
+	unknown.Event.Transaction.BaseFeePerGas = "30000000000"
+	require.NoError(t, est.Write(ctx, unknown))
+	// pending events and legacy transactions pending for 36s (3 blocks) by time
+	require.NoError(t, est.Write(ctx, dynamicTx("0xp", "", 200, 1)))
+	legacy := legacyTx("0xl", "", 0, 31)
+	legacy.Event.Transaction.Status = "confirmed"
+	legacy.Event.Transaction.BaseFeePerGas = "30000000000"
+	legacy.Event.Transaction.TimePending = "36000"
+	require.NoError(t, est.Write(ctx, legacy))
+
+	e, err := est.Estimate(90, 1)
+	require.NoError(t, err)
+	require.Equal(t, 9.0, e.PriorityFee)
+	require.Equal(t, 30*1.125+9, e.MaxFee)
+	require.Equal(t, 30.0, e.BaseFee)
+	require.Equal(t, 21, e.Samples)
+
+	e, err = est.Estimate(50, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, e.PriorityFee)
+
+	e, err = est.Estimate(99, 4)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, e.PriorityFee)
+
+	_, err = est.Estimate(0, 1)
+	require.Error(t, err)
+	_, err = est.Estimate(90, 0)
+	require.Error(t, err)
+
+	now = now.Add(2 * time.Hour)
+	_, err = est.Estimate(90, 1)
+	require.ErrorIs(t, err, ErrNotEnoughData)
+}
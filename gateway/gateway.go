@@ -0,0 +1,258 @@
+// Package gateway fans out events from a single upstream blocknative
+// connection to many downstream consumers
+package gateway
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// DefaultBufferSize is the number of events buffered for each subscription
+const DefaultBufferSize = 256
+
+// Upstream manages the watches on the upstream connection, it is
+// implemented by client.Client
+type Upstream interface {
+	WatchAddress(ctx context.Context, address string) error
+	UnwatchAddress(ctx context.Context, address string) error
+	WatchTx(ctx context.Context, txHash string) error
+	UnwatchTx(ctx context.Context, txHash string) error
+}
+
+// Filter selects the events delivered to a subscription
+type Filter struct {
+	Addresses []string `json:"addresses"`
+	TxHashes  []string `json:"txHashes"`
+}
+
+// normalize lowercases and deduplicates the filter
+func (f Filter) normalize() Filter {
+	return Filter{Addresses: normalize(f.Addresses), TxHashes: normalize(f.TxHashes)}
+}
+
+func normalize(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+// Gateway shares an upstream connection between subscriptions, watching
+// an address or transaction upstream for as long as at least one
+// subscription references it
+type Gateway struct {
+	upstream   Upstream
+	bufferSize int
+	// watchMx serializes changes to the upstream watches
+	watchMx   sync.Mutex
+	mx        sync.Mutex
+	addresses map[string]int
+	txHashes  map[string]int
+	subs      map[*Subscription]struct{}
+}
+
+// New returns a new gateway managing watches on upstream
+func New(upstream Upstream) *Gateway {
+	return &Gateway{
+		upstream:   upstream,
+		bufferSize: DefaultBufferSize,
+		addresses:  make(map[string]int),
+		txHashes:   make(map[string]int),
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the events matching its filter
+type Subscription struct {
+	filter    Filter
+	addresses map[string]bool
+	txHashes  map[string]bool
+	events    chan *client.EthTxPayload
+	gateway   *Gateway
+	once      sync.Once
+}
+
+// Events returns the channel events are delivered on, it is closed when the
+// subscription is closed. Events are dropped if the subscriber falls behind.
+func (s *Subscription) Events() <-chan *client.EthTxPayload {
+	return s.events
+}
+
+// Filter returns the normalized filter of the subscription
+func (s *Subscription) Filter() Filter {
+	return s.filter
+}
+
+// Close releases the upstream watches held by the subscription
+func (s *Subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		err = s.gateway.unsubscribe(context.Background(), s)
+	})
+	return err
+}
+
+func (s *Subscription) matches(msg *client.EthTxPayload) bool {
+	tx := msg.Event.Transaction
+	if s.txHashes[strings.ToLower(tx.Hash)] {
+		return true
+	}
+	for _, address := range []string{tx.WatchedAddress, tx.From, tx.To} {
+		if s.addresses[strings.ToLower(address)] {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe registers a new subscription for filter, adding upstream
+// watches for any address or transaction not yet being watched
+func (g *Gateway) Subscribe(ctx context.Context, filter Filter) (*Subscription, error) {
+	filter = filter.normalize()
+	if len(filter.Addresses) == 0 && len(filter.TxHashes) == 0 {
+		return nil, errors.New("filter must contain at least one address or transaction hash")
+	}
+	sub := &Subscription{
+		filter:    filter,
+		addresses: make(map[string]bool, len(filter.Addresses)),
+		txHashes:  make(map[string]bool, len(filter.TxHashes)),
+		events:    make(chan *client.EthTxPayload, g.bufferSize),
+		gateway:   g,
+	}
+	for _, address := range filter.Addresses {
+		sub.addresses[address] = true
+	}
+	for _, hash := range filter.TxHashes {
+		sub.txHashes[hash] = true
+	}
+	refs := g.refs(filter)
+	g.watchMx.Lock()
+	defer g.watchMx.Unlock()
+	g.mx.Lock()
+	added := acquire(refs)
+	g.mx.Unlock()
+	// upstream is called without holding mx so that a slow upstream doesn't hold up Handle
+	for i, r := range added {
+		if err := r.watch(ctx, r.key); err != nil {
+			g.mx.Lock()
+			release(refs)
+			g.mx.Unlock()
+			g.unwatch(ctx, added[:i])
+			return nil, errors.Wrapf(err, "watching %v:%v", r.kind, r.key)
+		}
+	}
+	g.mx.Lock()
+	g.subs[sub] = struct{}{}
+	g.mx.Unlock()
+	return sub, nil
+}
+
+func (g *Gateway) unsubscribe(ctx context.Context, sub *Subscription) error {
+	g.watchMx.Lock()
+	defer g.watchMx.Unlock()
+	g.mx.Lock()
+	delete(g.subs, sub)
+	close(sub.events)
+	removed := release(g.refs(sub.filter))
+	g.mx.Unlock()
+	return g.unwatch(ctx, removed)
+}
+
+// ref is a reference held by a subscription on an upstream watch
+type ref struct {
+	kind    string
+	key     string
+	counts  map[string]int
+	watch   func(context.Context, string) error
+	unwatch func(context.Context, string) error
+}
+
+// refs returns the references held by a subscription to the normalized filter
+func (g *Gateway) refs(filter Filter) []ref {
+	refs := make([]ref, 0, len(filter.Addresses)+len(filter.TxHashes))
+	for _, address := range filter.Addresses {
+		refs = append(refs, ref{"address", address, g.addresses, g.upstream.WatchAddress, g.upstream.UnwatchAddress})
+	}
+	for _, hash := range filter.TxHashes {
+		refs = append(refs, ref{"tx", hash, g.txHashes, g.upstream.WatchTx, g.upstream.UnwatchTx})
+	}
+	return refs
+}
+
+// acquire increments the reference counts of refs, returning those which need watching upstream
+func acquire(refs []ref) []ref {
+	var added []ref
+	for _, r := range refs {
+		if r.counts[r.key]++; r.counts[r.key] == 1 {
+			added = append(added, r)
+		}
+	}
+	return added
+}
+
+// release decrements the reference counts of refs, returning those which are no longer referenced
+func release(refs []ref) []ref {
+	var removed []ref
+	for _, r := range refs {
+		if r.counts[r.key]--; r.counts[r.key] > 0 {
+			continue
+		}
+		delete(r.counts, r.key)
+		removed = append(removed, r)
+	}
+	return removed
+}
+
+// unwatch removes the upstream watches of refs, returning the first error encountered
+func (g *Gateway) unwatch(ctx context.Context, refs []ref) error {
+	var first error
+	for _, r := range refs {
+		if err := r.unwatch(ctx, r.key); err != nil && first == nil {
+			first = errors.Wrapf(err, "unwatching %v:%v", r.kind, r.key)
+		}
+	}
+	return first
+}
+
+// Handle fans msg out to every matching subscription, it has the
+// signature of client.Handler so it can be passed to Client.Listen
+func (g *Gateway) Handle(_ context.Context, msg *client.EthTxPayload) error {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	for sub := range g.subs {
+		if !sub.matches(msg) {
+			continue
+		}
+		select {
+		case sub.events <- msg:
+		default:
+		}
+	}
+	return nil
+}
+
+// Watched returns the number of subscriptions referencing each watched address and transaction hash
+func (g *Gateway) Watched() (addresses, txHashes map[string]int) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	addresses = make(map[string]int, len(g.addresses))
+	for k, v := range g.addresses {
+		addresses[k] = v
+	}
+	txHashes = make(map[string]int, len(g.txHashes))
+	for k, v := range g.txHashes {
+		txHashes[k] = v
+	}
+	return addresses, txHashes
+}
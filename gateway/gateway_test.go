@@ -0,0 +1,218 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+type fakeUpstream struct {
+	mx    sync.Mutex
+	calls []string
+	// fail is a call which returns an error
+	fail string
+	// block delays watching addresses until it is closed
+	block chan struct{}
+}
+
+func (f *fakeUpstream) record(call string) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if call == f.fail {
+		return errors.New("upstream failure")
+	}
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *fakeUpstream) WatchAddress(_ context.Context, address string) error {
+	if f.block != nil {
+		<-f.block
+	}
+	return f.record("watch " + address)
+}
+
+func (f *fakeUpstream) UnwatchAddress(_ context.Context, address string) error {
+	return f.record("unwatch " + address)
+}
+
+func (f *fakeUpstream) WatchTx(_ context.Context, txHash string) error {
+	return f.record("watchTx " + txHash)
+}
+
+func (f *fakeUpstream) UnwatchTx(_ context.Context, txHash string) error {
+	return f.record("unwatchTx " + txHash)
+}
+
+func (f *fakeUpstream) Calls() []string {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func event(hash, watched string) *client.EthTxPayload {
+	msg := &client.EthTxPayload{}
+	msg.Event.EventCode = "txPool"
+	msg.Event.Transaction.Hash = hash
+	msg.Event.Transaction.WatchedAddress = watched
+	return msg
+}
+
+func TestGatewayReferenceCounting(t *testing.T) {
+	up := &fakeUpstream{}
+	g := New(up)
+	ctx := context.Background()
+
+	s1, err := g.Subscribe(ctx, Filter{Addresses: []string{"0xAA", "0xbb"}})
+	require.NoError(t, err)
+	s2, err := g.Subscribe(ctx, Filter{Addresses: []string{"0xaa"}, TxHashes: []string{"0x01"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"watch 0xaa", "watch 0xbb", "watchTx 0x01"}, up.Calls())
+
+	require.NoError(t, g.Handle(ctx, event("0x02", "0xAA")))
+	require.NoError(t, g.Handle(ctx, event("0x01", "")))
+	require.Equal(t, "0x02", (<-s1.Events()).Event.Transaction.Hash)
+	require.Equal(t, "0x02", (<-s2.Events()).Event.Transaction.Hash)
+	require.Equal(t, "0x01", (<-s2.Events()).Event.Transaction.Hash)
+	require.Len(t, s1.Events(), 0)
+
+	require.NoError(t, s1.Close())
+	require.NoError(t, s1.Close())
+	require.Equal(t, "unwatch 0xbb", up.Calls()[3])
+	addresses, _ := g.Watched()
+	require.Equal(t, map[string]int{"0xaa": 1}, addresses)
+
+	require.NoError(t, s2.Close())
+	require.ElementsMatch(t, []string{"unwatch 0xaa", "unwatchTx 0x01"}, up.Calls()[4:])
+
+	_, err = g.Subscribe(ctx, Filter{})
+	require.Error(t, err)
+}
+
+func TestGatewaySubscribeFailure(t *testing.T) {
+	up := &fakeUpstream{fail: "watchTx 0x02"}
+	g := New(up)
+	ctx := context.Background()
+
+	s1, err := g.Subscribe(ctx, Filter{Addresses: []string{"0xaa"}})
+	require.NoError(t, err)
+	_, err = g.Subscribe(ctx, Filter{Addresses: []string{"0xaa", "0xbb"}, TxHashes: []string{"0x01", "0x02"}})
+	require.ErrorContains(t, err, "watching tx:0x02")
+	// the references are rolled back and the watches added upstream removed
+	addresses, txHashes := g.Watched()
+	require.Equal(t, map[string]int{"0xaa": 1}, addresses)
+	require.Empty(t, txHashes)
+	require.Equal(t, []string{"watch 0xaa", "watch 0xbb", "watchTx 0x01", "unwatch 0xbb", "unwatchTx 0x01"}, up.Calls())
+	require.NoError(t, s1.Close())
+}
+
+func TestGatewaySlowUpstream(t *testing.T) {
+	up := &fakeUpstream{}
+	g := New(up)
+	ctx := context.Background()
+	s1, err := g.Subscribe(ctx, Filter{TxHashes: []string{"0x01"}})
+	require.NoError(t, err)
+
+	up.block = make(chan struct{})
+	subscribed := make(chan error, 1)
+	go func() {
+		_, err := g.Subscribe(ctx, Filter{Addresses: []string{"0xaa"}})
+		subscribed <- err
+	}()
+	require.Eventually(t, func() bool {
+		addresses, _ := g.Watched()
+		return addresses["0xaa"] == 1
+	}, time.Second, time.Millisecond)
+	// events are delivered while the upstream watch is pending
+	require.NoError(t, g.Handle(ctx, event("0x01", "")))
+	require.Equal(t, "0x01", (<-s1.Events()).Event.Transaction.Hash)
+	close(up.block)
+	require.NoError(t, <-subscribed)
+}
+
+func TestGatewayHTTP(t *testing.T) {
+	g := New(&fakeUpstream{})
+	srv := httptest.NewServer(g.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events?address=0xaa")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	conn, _, err := websocket.DefaultDialer.Dial(strings.Replace(srv.URL, "http", "ws", 1)+"/ws?tx=0x01,0x02", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// wait for both subscriptions to be registered
+	require.Eventually(t, func() bool {
+		addresses, txHashes := g.Watched()
+		return len(addresses) == 1 && len(txHashes) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, g.Handle(context.Background(), event("0x02", "0xaa")))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event: txPool\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	var msg client.EthTxPayload
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg))
+	require.Equal(t, "0x02", msg.Event.Transaction.Hash)
+
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, "0x02", msg.Event.Transaction.Hash)
+
+	badResp, err := http.Get(srv.URL + "/events")
+	require.NoError(t, err)
+	badResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, badResp.StatusCode)
+}
+
+func TestGatewayOrigins(t *testing.T) {
+	up := &fakeUpstream{}
+	srv := httptest.NewServer(New(up).Handler("https://app.example.com"))
+	defer srv.Close()
+	dial := func(origin string) (int, error) {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(strings.Replace(srv.URL, "http", "ws", 1)+"/ws?tx=0x01", header)
+		if err != nil {
+			return resp.StatusCode, err
+		}
+		conn.Close()
+		return resp.StatusCode, nil
+	}
+	for _, origin := range []string{"", srv.URL, "https://app.example.com"} {
+		_, err := dial(origin)
+		require.NoError(t, err, origin)
+	}
+	status, err := dial("https://evil.example.com")
+	require.Error(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// event streams are checked as well
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/events?address=0xaa", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.NotContains(t, up.Calls(), "watch 0xaa")
+}
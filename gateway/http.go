@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// Handler returns an http handler streaming events to downstream consumers
+// as server-sent events on /events and over websockets on /ws. The
+// subscription filter is given by the repeatable address and tx query
+// parameters, which also accept comma separated lists. Browsers may only
+// subscribe from pages served by the gateway's own host or one of origins,
+// "*" allows any origin.
+func (g *Gateway) Handler(origins ...string) http.Handler {
+	check := checkOrigin(origins)
+	upgrader := websocket.Upgrader{CheckOrigin: check}
+	// requests are rejected before subscribing so that no upstream watches are added
+	allowed := func(serve http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !check(r) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			serve(w, r)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", allowed(g.serveSSE))
+	mux.HandleFunc("/ws", allowed(func(w http.ResponseWriter, r *http.Request) {
+		g.serveWebsocket(upgrader, w, r)
+	}))
+	return mux
+}
+
+// checkOrigin allows requests without an origin, such as those of
+// non-browser clients, from the same host or from one of origins
+func checkOrigin(origins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		for _, allowed := range origins {
+			if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func filterFromQuery(query url.Values) Filter {
+	split := func(values []string) []string {
+		var out []string
+		for _, v := range values {
+			out = append(out, strings.Split(v, ",")...)
+		}
+		return out
+	}
+	return Filter{
+		Addresses: split(query["address"]),
+		TxHashes:  split(query["tx"]),
+	}
+}
+
+func (g *Gateway) serveSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	sub, err := g.Subscribe(r.Context(), filterFromQuery(r.URL.Query()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer sub.Close()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Event.EventCode, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (g *Gateway) serveWebsocket(upgrader websocket.Upgrader, w http.ResponseWriter, r *http.Request) {
+	sub, err := g.Subscribe(r.Context(), filterFromQuery(r.URL.Query()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer sub.Close()
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	// downstream consumers don't send anything, reading is only used to detect closure
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		}
+	}
+}
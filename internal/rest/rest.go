@@ -0,0 +1,146 @@
+// Package rest is the http client shared by the clients of the blocknative
+// rest apis, authenticating with the api key and retrying with backoff
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBaseURL is the url of the blocknative rest api
+	DefaultBaseURL = "https://api.blocknative.com"
+	// DefaultBackoff is the delay before the first retry of a request
+	DefaultBackoff = 250 * time.Millisecond
+)
+
+// Opts provides configuration over the rest client
+type Opts struct {
+	// API names the api in errors
+	API string
+	// APIKey is sent as the Authorization header, BLOCKNATIVE_DAPP_ID is used if empty
+	APIKey string
+	// BaseURL overrides DefaultBaseURL
+	BaseURL string
+	// MaxRetries is the number of times a failed request is retried
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubling on every attempt
+	Backoff    time.Duration
+	HTTPClient *http.Client
+}
+
+// Client sends requests to a blocknative rest api
+type Client struct {
+	opts Opts
+}
+
+// APIError is returned for requests the api responded to with an error status
+type APIError struct {
+	API        string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return e.API + " api error status:" + strconv.Itoa(e.StatusCode) + " message:" + e.Message
+}
+
+// NewClient returns a new rest client
+func NewClient(opts Opts) *Client {
+	if opts.APIKey == "" {
+		opts.APIKey = os.Getenv("BLOCKNATIVE_DAPP_ID")
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &Client{opts: opts}
+}
+
+// APIKey returns the api key requests are authenticated with
+func (c *Client) APIKey() string {
+	return c.opts.APIKey
+}
+
+// Do sends body as the json body of a request to path, returning the
+// response body. Unless retry is false, failed requests are retried with
+// backoff on network errors, server errors and rate limiting.
+func (c *Client) Do(ctx context.Context, method, path string, body []byte, retry bool) ([]byte, error) {
+	retries := c.opts.MaxRetries
+	if !retry {
+		retries = 0
+	}
+	backoff := c.opts.Backoff
+	var (
+		resp      []byte
+		err       error
+		retryable bool
+	)
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			backoff *= 2
+		}
+		if resp, retryable, err = c.send(ctx, method, path, body); err == nil || !retryable {
+			break
+		}
+	}
+	return resp, err
+}
+
+// send performs a single request, reporting whether a failure may be retried
+func (c *Client) send(ctx context.Context, method, path string, body []byte) (resp []byte, retry bool, err error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.opts.BaseURL+path, reader)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Authorization", c.opts.APIKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, errors.Wrapf(err, "requesting %v api", c.opts.API)
+	}
+	defer res.Body.Close()
+	resp, err = io.ReadAll(res.Body)
+	if err != nil {
+		return nil, true, errors.Wrapf(err, "reading %v api response", c.opts.API)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		apiErr := &APIError{API: c.opts.API, StatusCode: res.StatusCode, Message: strings.TrimSpace(string(resp))}
+		var msg struct {
+			Msg   string `json:"msg"`
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(resp, &msg) == nil && (msg.Msg != "" || msg.Error != "") {
+			apiErr.Message = msg.Msg + msg.Error
+		}
+		return nil, res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests, apiErr
+	}
+	return resp, false, nil
+}
@@ -0,0 +1,39 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.Header.Get("Authorization"))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": "rate limited"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(Opts{API: "test", APIKey: "key", BaseURL: srv.URL + "/", MaxRetries: 2, Backoff: time.Millisecond})
+
+	// requests which may not be retried fail on the first error
+	_, err := c.Do(context.Background(), http.MethodPost, "/path", []byte(`{}`), false)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	require.EqualError(t, err, "test api error status:429 message:rate limited")
+
+	resp, err := c.Do(context.Background(), http.MethodGet, "/path", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, `{}`, string(resp))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,89 @@
+package output
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultColumns are the columns used by the csv and table formats when none are selected
+var DefaultColumns = []string{"timeStamp", "eventCode", "status", "hash", "from", "to", "value"}
+
+// columns maps column names to the event field they extract
+var columns = map[string]func(r Record) string{
+	"timeStamp":            func(r Record) string { return formatTime(r.Event.Transaction.TimeStamp) },
+	"eventCode":            func(r Record) string { return r.Event.EventCode },
+	"categoryCode":         func(r Record) string { return r.Event.CategoryCode },
+	"system":               func(r Record) string { return r.Blockchain.System },
+	"network":              func(r Record) string { return r.Blockchain.Network },
+	"status":               func(r Record) string { return r.Event.Transaction.Status },
+	"hash":                 func(r Record) string { return r.Event.Transaction.Hash },
+	"from":                 func(r Record) string { return r.Event.Transaction.From },
+	"to":                   func(r Record) string { return r.Event.Transaction.To },
+	"value":                func(r Record) string { return r.Event.Transaction.Value },
+	"type":                 func(r Record) string { return strconv.FormatUint(r.Event.Transaction.Type, 10) },
+	"gas":                  func(r Record) string { return formatFloat(r.Event.Transaction.Gas) },
+	"gasUsed":              func(r Record) string { return formatFloat(r.Event.Transaction.GasUsed) },
+	"gasPrice":             func(r Record) string { return r.Event.Transaction.GasPrice },
+	"maxFeePerGas":         func(r Record) string { return r.Event.Transaction.MaxFeePerGas },
+	"maxPriorityFeePerGas": func(r Record) string { return r.Event.Transaction.MaxPriorityFeePerGas },
+	"baseFeePerGas":        func(r Record) string { return r.Event.Transaction.BaseFeePerGas },
+	"nonce":                func(r Record) string { return strconv.FormatUint(r.Event.Transaction.Nonce, 10) },
+	"blockHash":            func(r Record) string { return r.Event.Transaction.BlockHash },
+	"blockNumber": func(r Record) string {
+		return strconv.FormatUint(r.Event.Transaction.BlockNumber, 10)
+	},
+	"transactionIndex": func(r Record) string {
+		return strconv.FormatUint(r.Event.Transaction.TransactionIndex, 10)
+	},
+	"input":          func(r Record) string { return r.Event.Transaction.Input },
+	"asset":          func(r Record) string { return r.Event.Transaction.Asset },
+	"watchedAddress": func(r Record) string { return r.Event.Transaction.WatchedAddress },
+	"direction":      func(r Record) string { return r.Event.Transaction.Direction },
+	"counterparty":   func(r Record) string { return r.Event.Transaction.Counterparty },
+	"timePending":    func(r Record) string { return r.Event.Transaction.TimePending },
+	"label":          func(r Record) string { return r.Label },
+	"simError":       func(r Record) string { return r.Event.Transaction.SimError },
+	"blocksPending": func(r Record) string {
+		return strconv.FormatUint(r.Event.Transaction.BlocksPending, 10)
+	},
+}
+
+// Columns returns the names of all supported columns
+func Columns() []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// extractors returns the field extractors for the named columns
+func extractors(names []string) ([]func(Record) string, error) {
+	if len(names) == 0 {
+		names = DefaultColumns
+	}
+	out := make([]func(Record) string, len(names))
+	for i, name := range names {
+		fn, ok := columns[name]
+		if !ok {
+			return nil, errors.Errorf("unknown column:%v", name)
+		}
+		out[i] = fn
+	}
+	return out, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,422 @@
+// Package output encodes events received from the blocknative api
+// into formats suitable for other tools
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/calltree"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// Supported formats
+const (
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatCSV      = "csv"
+	FormatTable    = "table"
+	FormatTemplate = "template"
+	FormatTree     = "tree"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatJSON, FormatNDJSON, FormatCSV, FormatTable, FormatTemplate, FormatTree}
+
+// Options selects the format events are encoded in
+type Options struct {
+	// Format is one of Formats
+	Format string
+	// Columns used by the csv and table formats, DefaultColumns if empty
+	Columns []string
+	// Template is a text/template executed against each Record for the template format
+	Template string
+	// Labels maps lowercase addresses to the label added to records of events involving them
+	Labels map[string]string
+	// Blockchain fills in the system and network of records of events which do not carry their own
+	Blockchain client.Blockchain
+}
+
+// Record is the value encoded for every event, the event is embedded so
+// its fields are encoded inline next to the label of the address it involves
+// and the network it was received from
+type Record struct {
+	*client.EthTxPayload
+	Label      string            `json:"label,omitempty"`
+	Blockchain client.Blockchain `json:"blockchain"`
+}
+
+// NewRecord returns the record for msg, labelled with the label of the
+// watched address or failing that the sender or recipient
+func NewRecord(msg *client.EthTxPayload, labels map[string]string) Record {
+	r := Record{EthTxPayload: msg, Blockchain: msg.Event.Blockchain}
+	tx := msg.Event.Transaction
+	for _, address := range []string{tx.WatchedAddress, tx.From, tx.To} {
+		if label, ok := labels[strings.ToLower(address)]; ok && address != "" {
+			r.Label = label
+			break
+		}
+	}
+	return r
+}
+
+// Encoder encodes events into a format
+type Encoder interface {
+	// Header is written at the start of every output, it may be nil
+	Header() []byte
+	// Encode returns the encoded record for msg
+	Encode(msg *client.EthTxPayload) ([]byte, error)
+}
+
+// recordEncoder encodes records into a format
+type recordEncoder interface {
+	Header() []byte
+	Encode(r Record) ([]byte, error)
+}
+
+// framer is implemented by encoders whose records are enclosed in a
+// structure, such as the array of the json format
+type framer interface {
+	// Separator is written between records
+	Separator() []byte
+	// Footer is written at the end of every output
+	Footer() []byte
+}
+
+// labelEncoder turns events into labelled records for a recordEncoder
+type labelEncoder struct {
+	recordEncoder
+	labels     map[string]string
+	blockchain client.Blockchain
+}
+
+func (e labelEncoder) Encode(msg *client.EthTxPayload) ([]byte, error) {
+	r := NewRecord(msg, e.labels)
+	if r.Blockchain.System == "" {
+		r.Blockchain.System = e.blockchain.System
+	}
+	if r.Blockchain.Network == "" {
+		r.Blockchain.Network = e.blockchain.Network
+	}
+	return e.recordEncoder.Encode(r)
+}
+
+func (e labelEncoder) Separator() []byte {
+	if f, ok := e.recordEncoder.(framer); ok {
+		return f.Separator()
+	}
+	return nil
+}
+
+func (e labelEncoder) Footer() []byte {
+	if f, ok := e.recordEncoder.(framer); ok {
+		return f.Footer()
+	}
+	return nil
+}
+
+// NewEncoder returns an encoder for the format selected by opts
+func NewEncoder(opts Options) (Encoder, error) {
+	var enc recordEncoder
+	switch opts.Format {
+	case FormatJSON:
+		enc = jsonEncoder{array: true}
+	case FormatNDJSON:
+		enc = jsonEncoder{}
+	case FormatCSV:
+		fields, err := extractors(opts.Columns)
+		if err != nil {
+			return nil, err
+		}
+		enc = csvEncoder{names: columnNames(opts.Columns), fields: fields}
+	case FormatTable:
+		fields, err := extractors(opts.Columns)
+		if err != nil {
+			return nil, err
+		}
+		enc = tableEncoder{names: columnNames(opts.Columns), fields: fields}
+	case FormatTemplate:
+		tmpl, err := template.New("output").Parse(opts.Template)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing template")
+		}
+		enc = templateEncoder{tmpl: tmpl}
+	case FormatTree:
+		enc = treeEncoder{}
+	default:
+		return nil, errors.Errorf("unsupported output format:%v", opts.Format)
+	}
+	return labelEncoder{recordEncoder: enc, labels: opts.Labels, blockchain: opts.Blockchain}, nil
+}
+
+func columnNames(names []string) []string {
+	if len(names) == 0 {
+		return DefaultColumns
+	}
+	return names
+}
+
+// jsonEncoder writes a line per record, or when array is set an indented array of records
+type jsonEncoder struct {
+	array bool
+}
+
+func (e jsonEncoder) Header() []byte {
+	if e.array {
+		return []byte("[\n")
+	}
+	return nil
+}
+
+func (e jsonEncoder) Separator() []byte {
+	if e.array {
+		return []byte(",\n")
+	}
+	return nil
+}
+
+func (e jsonEncoder) Footer() []byte {
+	if e.array {
+		return []byte("\n]\n")
+	}
+	return nil
+}
+
+func (e jsonEncoder) Encode(r Record) ([]byte, error) {
+	if e.array {
+		data, err := json.MarshalIndent(r, "  ", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte("  "), data...), nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type csvEncoder struct {
+	names  []string
+	fields []func(Record) string
+}
+
+func (e csvEncoder) Header() []byte {
+	data, _ := e.record(e.names)
+	return data
+}
+
+func (e csvEncoder) Encode(r Record) ([]byte, error) {
+	values := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		values[i] = field(r)
+	}
+	return e.record(values)
+}
+
+func (e csvEncoder) record(values []string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(values); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// tableEncoder writes fixed width columns so that rows line up as they are streamed
+type tableEncoder struct {
+	names  []string
+	fields []func(Record) string
+}
+
+// columnWidths holds the width of columns whose values have a known length
+var columnWidths = map[string]int{
+	"timeStamp":      24,
+	"hash":           66,
+	"blockHash":      66,
+	"from":           42,
+	"to":             42,
+	"watchedAddress": 42,
+	"counterparty":   42,
+	"value":          24,
+}
+
+func (e tableEncoder) width(i int) int {
+	if w, ok := columnWidths[e.names[i]]; ok {
+		return w
+	}
+	if len(e.names[i]) > 12 {
+		return len(e.names[i])
+	}
+	return 12
+}
+
+func (e tableEncoder) row(values []string) []byte {
+	var buf bytes.Buffer
+	for i, v := range values {
+		if i == len(values)-1 {
+			buf.WriteString(v)
+			break
+		}
+		fmt.Fprintf(&buf, "%-*s  ", e.width(i), v)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func (e tableEncoder) Header() []byte {
+	upper := make([]string, len(e.names))
+	for i, name := range e.names {
+		upper[i] = strings.ToUpper(name)
+	}
+	return e.row(upper)
+}
+
+func (e tableEncoder) Encode(r Record) ([]byte, error) {
+	values := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		values[i] = field(r)
+	}
+	return e.row(values), nil
+}
+
+type templateEncoder struct {
+	tmpl *template.Template
+}
+
+func (e templateEncoder) Header() []byte { return nil }
+
+func (e templateEncoder) Encode(r Record) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := e.tmpl.Execute(&buf, r); err != nil {
+		return nil, err
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// treeEncoder writes the call tree of every transaction followed by the ether it moves
+type treeEncoder struct{}
+
+func (e treeEncoder) Header() []byte { return nil }
+
+func (e treeEncoder) Encode(r Record) ([]byte, error) {
+	var buf bytes.Buffer
+	tx := &r.Event.Transaction
+	fmt.Fprintf(&buf, "%s %s %s", tx.Hash, tx.Status, r.Event.EventCode)
+	if r.Label != "" {
+		fmt.Fprintf(&buf, " (%s)", r.Label)
+	}
+	buf.WriteByte('\n')
+	root := calltree.Build(tx)
+	if err := calltree.Fprint(&buf, root); err != nil {
+		return nil, err
+	}
+	if flows := calltree.Flows(root); len(flows) > 0 {
+		buf.WriteString("ether flows:\n")
+		if err := calltree.FprintFlows(&buf, flows); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// framedOutput is implemented by outputs which frame records themselves,
+// such as a RotatingFile that starts every file with the header and ends it
+// with the footer
+type framedOutput interface {
+	SetFraming(header, separator, footer []byte)
+}
+
+// Writer writes encoded events to an io.Writer, it implements sink.Sink
+type Writer struct {
+	enc       Encoder
+	out       io.Writer
+	mx        sync.Mutex
+	framed    bool
+	separator []byte
+	footer    []byte
+	started   bool
+	records   int
+}
+
+// NewWriter returns a writer encoding events with enc
+func NewWriter(out io.Writer, enc Encoder) *Writer {
+	w := &Writer{enc: enc, out: out}
+	if f, ok := enc.(framer); ok {
+		w.separator, w.footer = f.Separator(), f.Footer()
+	}
+	if fo, ok := out.(framedOutput); ok {
+		fo.SetFraming(enc.Header(), w.separator, w.footer)
+		w.framed = true
+	}
+	return w
+}
+
+// Write encodes msg and writes it to the output
+func (w *Writer) Write(_ context.Context, msg *client.EthTxPayload) error {
+	data, err := w.enc.Encode(msg)
+	if err != nil {
+		return errors.Wrap(err, "encoding event")
+	}
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	if !w.framed {
+		if err := w.start(); err != nil {
+			return err
+		}
+		if w.records > 0 && len(w.separator) > 0 {
+			data = append(append([]byte(nil), w.separator...), data...)
+		}
+	}
+	if _, err = w.out.Write(data); err != nil {
+		return err
+	}
+	w.records++
+	return nil
+}
+
+// start writes the header if it has not been written yet
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	if header := w.enc.Header(); header != nil {
+		_, err := w.out.Write(header)
+		return err
+	}
+	return nil
+}
+
+// Close writes the footer of formats which have one and closes the output if it is an io.Closer
+func (w *Writer) Close() error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	if !w.framed && len(w.footer) > 0 {
+		if err := w.start(); err != nil {
+			return err
+		}
+		if _, err := w.out.Write(w.footer); err != nil {
+			return err
+		}
+		w.footer = nil
+	}
+	if c, ok := w.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+func testEvent(hash string) *client.EthTxPayload {
+	msg := &client.EthTxPayload{}
+	msg.Event.EventCode = "txPool"
+	msg.Event.Network = "main"
+	msg.Event.Transaction.Hash = hash
+	msg.Event.Transaction.Status = "pending"
+	msg.Event.Transaction.From = "0xaa"
+	msg.Event.Transaction.To = "0xbb"
+	msg.Event.Transaction.Value = "1,000"
+	return msg
+}
+
+func write(t *testing.T, opts Options, msgs ...*client.EthTxPayload) string {
+	enc, err := NewEncoder(opts)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, enc)
+	for _, msg := range msgs {
+		require.NoError(t, w.Write(context.Background(), msg))
+	}
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestFormats(t *testing.T) {
+	a, b := testEvent("0x01"), testEvent("0x02")
+
+	out := write(t, Options{Format: FormatNDJSON}, a, b)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	var decoded client.EthTxPayload
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &decoded))
+	require.Equal(t, "0x02", decoded.Event.Transaction.Hash)
+
+	// the json format is a single indented array
+	out = write(t, Options{Format: FormatJSON}, a, b)
+	var array []client.EthTxPayload
+	require.NoError(t, json.Unmarshal([]byte(out), &array))
+	require.Len(t, array, 2)
+	require.Equal(t, "0x02", array[1].Event.Transaction.Hash)
+	require.True(t, strings.HasPrefix(out, "[\n  {\n    "))
+	require.NoError(t, json.Unmarshal([]byte(write(t, Options{Format: FormatJSON})), &array))
+	require.Empty(t, array)
+
+	out = write(t, Options{Format: FormatCSV, Columns: []string{"hash", "value", "network"}}, a, b)
+	require.Equal(t, "hash,value,network\n0x01,\"1,000\",main\n0x02,\"1,000\",main\n", out)
+
+	out = write(t, Options{Format: FormatTable, Columns: []string{"status", "hash"}}, a)
+	require.Equal(t, "STATUS        HASH\npending       0x01\n", out)
+
+	out = write(t, Options{Format: FormatTemplate, Template: "{{.Event.Transaction.Hash}} {{.Event.Transaction.Status}}"}, a, b)
+	require.Equal(t, "0x01 pending\n0x02 pending\n", out)
+
+	tree := testEvent("0x04")
+	tree.Event.Transaction.InternalTransactions = []client.InternalTransaction{{Type: "CALL", From: "0xbb", To: "0xcc", Value: "2000000000000000000"}}
+	out = write(t, Options{Format: FormatTree}, tree)
+	require.Equal(t, "0x04 pending txPool\nCALL 0xaa -> 0xbb\n└── CALL 0xbb -> 0xcc 2 ETH\nether flows:\n0xbb in 0 out 2 net -2 ETH\n0xcc in 2 out 0 net +2 ETH\n\n", out)
+
+	labels := map[string]string{"0xbb": "exchange"}
+	out = write(t, Options{Format: FormatCSV, Columns: []string{"hash", "label"}, Labels: labels}, a)
+	require.Equal(t, "hash,label\n0x01,exchange\n", out)
+	out = write(t, Options{Format: FormatNDJSON, Labels: labels}, a)
+	var record struct {
+		Label string `json:"label"`
+		Event struct {
+			Transaction struct {
+				Hash string `json:"hash"`
+			} `json:"transaction"`
+		} `json:"event"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &record))
+	require.Equal(t, "exchange", record.Label)
+	require.Equal(t, "0x01", record.Event.Transaction.Hash)
+
+	// events without a system or network report the configured ones
+	chain := client.Blockchain{System: "ethereum", Network: "goerli"}
+	unset := testEvent("0x03")
+	unset.Event.Network = ""
+	out = write(t, Options{Format: FormatCSV, Columns: []string{"hash", "system", "network"}, Blockchain: chain}, a, unset)
+	require.Equal(t, "hash,system,network\n0x01,ethereum,main\n0x03,ethereum,goerli\n", out)
+
+	_, err := NewEncoder(Options{Format: FormatCSV, Columns: []string{"nope"}})
+	require.Error(t, err)
+	_, err = NewEncoder(Options{Format: "xml"})
+	require.Error(t, err)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.csv")
+	rf, err := NewRotatingFile(path, 40, 2)
+	require.NoError(t, err)
+	enc, err := NewEncoder(Options{Format: FormatCSV, Columns: []string{"hash", "status"}})
+	require.NoError(t, err)
+	w := NewWriter(rf, enc)
+	for _, hash := range []string{"0x01", "0x02", "0x03", "0x04", "0x05", "0x06", "0x07"} {
+		require.NoError(t, w.Write(context.Background(), testEvent(hash)))
+	}
+	require.NoError(t, w.Close())
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(data), "hash,status\n"), name)
+		require.LessOrEqual(t, len(data), 40)
+	}
+	_, err = os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "0x07")
+}
+
+func TestRotatingFileJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	enc, err := NewEncoder(Options{Format: FormatJSON})
+	require.NoError(t, err)
+	hashes := func(name string) []string {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		var array []client.EthTxPayload
+		require.NoError(t, json.Unmarshal(data, &array), name)
+		out := make([]string, len(array))
+		for i, msg := range array {
+			out[i] = msg.Event.Transaction.Hash
+		}
+		return out
+	}
+	writeAll := func(maxSize int64, hashes ...string) {
+		rf, err := NewRotatingFile(path, maxSize, 2)
+		require.NoError(t, err)
+		w := NewWriter(rf, enc)
+		for _, hash := range hashes {
+			require.NoError(t, w.Write(context.Background(), testEvent(hash)))
+		}
+		require.NoError(t, w.Close())
+	}
+
+	// every file is a complete array, and reopening appends to it
+	writeAll(0, "0x01", "0x02")
+	require.Equal(t, []string{"0x01", "0x02"}, hashes(path))
+	writeAll(0, "0x03")
+	require.Equal(t, []string{"0x01", "0x02", "0x03"}, hashes(path))
+
+	writeAll(1, "0x04", "0x05")
+	require.Equal(t, []string{"0x01", "0x02", "0x03"}, hashes(path+".2"))
+	require.Equal(t, []string{"0x04"}, hashes(path+".1"))
+	require.Equal(t, []string{"0x05"}, hashes(path))
+}
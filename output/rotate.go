@@ -0,0 +1,165 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RotatingFile is an io.WriteCloser that rotates the file at path once it
+// would grow beyond MaxSize bytes, keeping up to MaxBackups previous files
+// named path.1 (newest) to path.N (oldest). Every write is taken to be a
+// record, framed as set by SetFraming.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	header     []byte
+	separator  []byte
+	footer     []byte
+	mx         sync.Mutex
+	file       *os.File
+	size       int64
+	// records is set once the current file holds a record
+	records bool
+}
+
+// NewRotatingFile opens path for appending, maxSize <= 0 disables rotation
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// SetHeader sets the data written at the start of every new file
+func (rf *RotatingFile) SetHeader(header []byte) {
+	rf.SetFraming(header, nil, nil)
+}
+
+// SetFraming sets the data written at the start of every new file, between
+// records and at the end of every file. The footer of an existing file is
+// dropped so that records can be appended to it.
+func (rf *RotatingFile) SetFraming(header, separator, footer []byte) {
+	rf.mx.Lock()
+	defer rf.mx.Unlock()
+	rf.header, rf.separator, rf.footer = header, separator, footer
+	_ = rf.resume()
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "opening output file")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rf.file, rf.size = file, info.Size()
+	return rf.resume()
+}
+
+// resume writes the header to an empty file, or drops the footer of a
+// file which already has records
+func (rf *RotatingFile) resume() error {
+	if rf.size == 0 {
+		rf.records = false
+		if len(rf.header) == 0 {
+			return nil
+		}
+		n, err := rf.file.Write(rf.header)
+		rf.size += int64(n)
+		return err
+	}
+	if len(rf.footer) > 0 && rf.size >= int64(len(rf.footer)) {
+		data, err := os.ReadFile(rf.path)
+		if err != nil {
+			return err
+		}
+		if bytes.HasSuffix(data, rf.footer) {
+			rf.size -= int64(len(rf.footer))
+			if err := rf.file.Truncate(rf.size); err != nil {
+				return err
+			}
+		}
+	}
+	rf.records = rf.size > int64(len(rf.header))
+	return nil
+}
+
+// rotate shifts the existing backups, moves the current file to path.1 and opens a new one
+func (rf *RotatingFile) rotate() error {
+	if err := rf.finish(); err != nil {
+		return err
+	}
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	if rf.maxBackups <= 0 {
+		if err := os.Remove(rf.path); err != nil {
+			return err
+		}
+		return rf.open()
+	}
+	for i := rf.maxBackups - 1; i > 0; i-- {
+		from := fmt.Sprintf("%s.%d", rf.path, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", rf.path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+		return err
+	}
+	return rf.open()
+}
+
+// Write writes the record p to the current file, rotating first if it would exceed the maximum size
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mx.Lock()
+	defer rf.mx.Unlock()
+	if rf.maxSize > 0 && rf.records && rf.size+int64(len(rf.separator)+len(p)+len(rf.footer)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, errors.Wrap(err, "rotating output file")
+		}
+	}
+	if rf.records && len(rf.separator) > 0 {
+		n, err := rf.file.Write(rf.separator)
+		rf.size += int64(n)
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	rf.records = true
+	return n, err
+}
+
+// finish writes the footer at the end of the current file
+func (rf *RotatingFile) finish() error {
+	if len(rf.footer) == 0 {
+		return nil
+	}
+	n, err := rf.file.Write(rf.footer)
+	rf.size += int64(n)
+	return err
+}
+
+// Close writes the footer and closes the current file
+func (rf *RotatingFile) Close() error {
+	rf.mx.Lock()
+	defer rf.mx.Unlock()
+	if err := rf.finish(); err != nil {
+		rf.file.Close()
+		return err
+	}
+	return rf.file.Close()
+}
@@ -0,0 +1,198 @@
+// Package reconcile converges the subscriptions of a client on a desired state
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// Target is the client whose subscriptions are reconciled, it is implemented by *client.Client
+type Target interface {
+	Subscriptions() client.Subscriptions
+	WatchAddress(ctx context.Context, address string) error
+	UnwatchAddress(ctx context.Context, address string) error
+	WatchTx(ctx context.Context, txHash string) error
+	UnwatchTx(ctx context.Context, txHash string) error
+	SetConfig(ctx context.Context, cfg client.Config) error
+	RemoveConfig(ctx context.Context, scope string) error
+}
+
+// Kinds of actions
+const (
+	SetConfig      = "setConfig"
+	WatchAddress   = "watchAddress"
+	WatchTx        = "watchTx"
+	UnwatchAddress = "unwatchAddress"
+	UnwatchTx      = "unwatchTx"
+	RemoveConfig   = "removeConfig"
+)
+
+// Action is a change needed to converge on the desired state
+type Action struct {
+	Kind string `json:"kind"`
+	// Key is the address, transaction hash or config scope the action applies to
+	Key    string         `json:"key"`
+	Config *client.Config `json:"config,omitempty"`
+}
+
+func (a Action) String() string {
+	sign := "+"
+	if strings.HasPrefix(a.Kind, "unwatch") || a.Kind == RemoveConfig {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s %s %s", sign, a.Kind, a.Key)
+}
+
+// Plan returns the actions converging current on desired. Additions come
+// first so that nothing goes unmonitored while the plan is applied.
+// Addresses, transaction hashes and scopes are compared case insensitively.
+// Invalid desired configs are reported as an error.
+func Plan(current, desired client.Subscriptions) ([]Action, error) {
+	for _, cfg := range desired.Configs {
+		if err := cfg.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid config scope:%v", cfg.Scope)
+		}
+	}
+	var actions []Action
+	currentConfigs, err := configsByScope(current.Configs)
+	if err != nil {
+		return nil, err
+	}
+	desiredConfigs, err := configsByScope(desired.Configs)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range sortedScopes(desiredConfigs) {
+		want := desiredConfigs[scope]
+		if have, ok := currentConfigs[scope]; ok && have.json == want.json {
+			continue
+		}
+		cfg := want.cfg
+		actions = append(actions, Action{Kind: SetConfig, Key: cfg.Scope, Config: &cfg})
+	}
+	currentAddresses, desiredAddresses := set(current.Addresses), set(desired.Addresses)
+	currentTxs, desiredTxs := set(current.TxHashes), set(desired.TxHashes)
+	actions = append(actions, missing(WatchAddress, desiredAddresses, currentAddresses)...)
+	actions = append(actions, missing(WatchTx, desiredTxs, currentTxs)...)
+	actions = append(actions, missing(UnwatchAddress, currentAddresses, desiredAddresses)...)
+	actions = append(actions, missing(UnwatchTx, currentTxs, desiredTxs)...)
+	for _, scope := range sortedScopes(currentConfigs) {
+		if _, ok := desiredConfigs[scope]; !ok {
+			actions = append(actions, Action{Kind: RemoveConfig, Key: currentConfigs[scope].cfg.Scope})
+		}
+	}
+	return actions, nil
+}
+
+// Apply performs the actions against target, stopping at the first failure
+func Apply(ctx context.Context, target Target, actions []Action) error {
+	for _, a := range actions {
+		var err error
+		switch a.Kind {
+		case SetConfig:
+			err = target.SetConfig(ctx, *a.Config)
+		case WatchAddress:
+			err = target.WatchAddress(ctx, a.Key)
+		case WatchTx:
+			err = target.WatchTx(ctx, a.Key)
+		case UnwatchAddress:
+			err = target.UnwatchAddress(ctx, a.Key)
+		case UnwatchTx:
+			err = target.UnwatchTx(ctx, a.Key)
+		case RemoveConfig:
+			err = target.RemoveConfig(ctx, a.Key)
+		default:
+			err = errors.Errorf("unknown action kind:%v", a.Kind)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "applying action:%v", a)
+		}
+	}
+	return nil
+}
+
+// Reconciler converges the subscriptions of a target on a desired state
+type Reconciler struct {
+	target Target
+	// DryRun computes the actions without applying them
+	DryRun bool
+}
+
+// New returns a reconciler for target
+func New(target Target, dryRun bool) *Reconciler {
+	return &Reconciler{target: target, DryRun: dryRun}
+}
+
+// Reconcile compares the active subscriptions of the target with desired
+// and applies the actions needed to converge, returning them
+func (r *Reconciler) Reconcile(ctx context.Context, desired client.Subscriptions) ([]Action, error) {
+	actions, err := Plan(r.target.Subscriptions(), desired)
+	if err != nil || r.DryRun {
+		return actions, err
+	}
+	return actions, Apply(ctx, r.target, actions)
+}
+
+// config is a config along with its json encoding used for comparison
+type config struct {
+	cfg  client.Config
+	json string
+}
+
+func configsByScope(configs []client.Config) (map[string]config, error) {
+	out := make(map[string]config, len(configs))
+	for _, cfg := range configs {
+		normalized := cfg
+		normalized.Scope = strings.ToLower(cfg.Scope)
+		data, err := json.Marshal(normalized)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal config scope:%v", cfg.Scope)
+		}
+		out[normalized.Scope] = config{cfg: cfg, json: string(data)}
+	}
+	return out, nil
+}
+
+// set maps lowercase keys to their original form
+func set(keys []string) map[string]string {
+	out := make(map[string]string, len(keys))
+	for _, k := range keys {
+		out[strings.ToLower(k)] = k
+	}
+	return out
+}
+
+// missing returns an action of kind for every key of from not in to
+func missing(kind string, from, to map[string]string) []Action {
+	var actions []Action
+	for _, k := range sortedKeys(from) {
+		if _, ok := to[k]; !ok {
+			actions = append(actions, Action{Kind: kind, Key: from[k]})
+		}
+	}
+	return actions
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedScopes(m map[string]config) []string {
+	scopes := make([]string, 0, len(m))
+	for scope := range m {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
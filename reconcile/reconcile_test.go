@@ -0,0 +1,101 @@
+package reconcile
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+const (
+	addrA = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	addrB = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+)
+
+// fakeTarget records the calls made to it
+type fakeTarget struct {
+	subs  client.Subscriptions
+	calls []string
+}
+
+func (f *fakeTarget) Subscriptions() client.Subscriptions { return f.subs }
+func (f *fakeTarget) WatchAddress(_ context.Context, address string) error {
+	f.calls = append(f.calls, "watchAddress "+address)
+	return nil
+}
+func (f *fakeTarget) UnwatchAddress(_ context.Context, address string) error {
+	f.calls = append(f.calls, "unwatchAddress "+address)
+	return nil
+}
+func (f *fakeTarget) WatchTx(_ context.Context, txHash string) error {
+	f.calls = append(f.calls, "watchTx "+txHash)
+	return nil
+}
+func (f *fakeTarget) UnwatchTx(_ context.Context, txHash string) error {
+	f.calls = append(f.calls, "unwatchTx "+txHash)
+	return nil
+}
+func (f *fakeTarget) SetConfig(_ context.Context, cfg client.Config) error {
+	f.calls = append(f.calls, "setConfig "+cfg.Scope)
+	return nil
+}
+func (f *fakeTarget) RemoveConfig(_ context.Context, scope string) error {
+	f.calls = append(f.calls, "removeConfig "+scope)
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	pending := client.NewConfig(addrA, false, nil)
+	pending.Filters = []map[string]string{{"status": "pending"}}
+	confirmed := client.NewConfig(addrA, false, nil)
+	confirmed.Filters = []map[string]string{{"status": "confirmed"}}
+	global := client.NewConfig("global", false, nil)
+
+	target := &fakeTarget{subs: client.Subscriptions{
+		Addresses: []string{strings.ToLower(addrA), "0x01"},
+		TxHashes:  []string{"0xaa"},
+		Configs:   []client.Config{pending, global},
+	}}
+	desired := client.Subscriptions{
+		// compared case insensitively with the current state
+		Addresses: []string{addrA, addrB},
+		TxHashes:  []string{"0xAA", "0xbb"},
+		Configs:   []client.Config{confirmed},
+	}
+
+	actions, err := New(target, true).Reconcile(context.Background(), desired)
+	require.NoError(t, err)
+	require.Empty(t, target.calls)
+	lines := make([]string, len(actions))
+	for i, a := range actions {
+		lines[i] = a.String()
+	}
+	require.Equal(t, []string{
+		"+ setConfig " + addrA,
+		"+ watchAddress " + addrB,
+		"+ watchTx 0xbb",
+		"- unwatchAddress 0x01",
+		"- removeConfig global",
+	}, lines)
+
+	_, err = New(target, false).Reconcile(context.Background(), desired)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"setConfig " + addrA,
+		"watchAddress " + addrB,
+		"watchTx 0xbb",
+		"unwatchAddress 0x01",
+		"removeConfig global",
+	}, target.calls)
+
+	// a converged state needs no actions
+	actions, err = Plan(desired, desired)
+	require.NoError(t, err)
+	require.Empty(t, actions)
+
+	desired.Configs = append(desired.Configs, client.NewConfig("nope", false, nil))
+	_, err = New(target, false).Reconcile(context.Background(), desired)
+	require.Error(t, err)
+}
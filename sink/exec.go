@@ -0,0 +1,140 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// DefaultExecTimeout is the time a command may run for before it is killed
+const DefaultExecTimeout = 30 * time.Second
+
+// ExecOpts provides configuration over running commands for events
+type ExecOpts struct {
+	// Command is run with sh -c for every event
+	Command string
+	// Concurrency limits the number of commands running at once
+	Concurrency int
+	// Timeout after which a command is killed
+	Timeout time.Duration
+	// Stdout and Stderr receive the output of commands, it is discarded if nil
+	Stdout io.Writer
+	Stderr io.Writer
+	// OnError is called with the event of every command which failed
+	OnError func(msg *client.EthTxPayload, err error)
+}
+
+// Exec is a Sink that runs a command for every event, passing the event
+// as json on stdin and its key fields in BN_* environment variables
+type Exec struct {
+	opts ExecOpts
+	sem  chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewExec returns a new exec sink
+func NewExec(opts ExecOpts) (*Exec, error) {
+	if opts.Command == "" {
+		return nil, errors.New("no command provided")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultExecTimeout
+	}
+	return &Exec{opts: opts, sem: make(chan struct{}, opts.Concurrency)}, nil
+}
+
+// Write starts the command for msg. It blocks while the concurrency
+// limit is reached and returns once the command has started.
+func (e *Exec) Write(ctx context.Context, msg *client.EthTxPayload) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "marshal event")
+	}
+	select {
+	case e.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	e.wg.Add(1)
+	go func() {
+		defer func() {
+			<-e.sem
+			e.wg.Done()
+		}()
+		// commands outlive ctx so that Close can wait for running ones
+		if err := e.run(context.Background(), msg, body); err != nil && e.opts.OnError != nil {
+			e.opts.OnError(msg, err)
+		}
+	}()
+	return nil
+}
+
+func (e *Exec) run(ctx context.Context, msg *client.EthTxPayload, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, e.opts.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", e.opts.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = e.opts.Stdout
+	cmd.Stderr = e.opts.Stderr
+	cmd.Env = append(os.Environ(), Env(msg)...)
+	// stop waiting for the output of processes started by the command once it is killed
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return errors.Errorf("command timed out after %v", e.opts.Timeout)
+		}
+		return errors.Wrap(err, "running command")
+	}
+	return nil
+}
+
+// Close waits for running commands to exit
+func (e *Exec) Close() error {
+	e.wg.Wait()
+	return nil
+}
+
+// Env returns the BN_* environment variables describing msg
+func Env(msg *client.EthTxPayload) []string {
+	ev := msg.Event
+	tx := ev.Transaction
+	vars := []struct{ name, value string }{
+		{"BN_CATEGORY_CODE", ev.CategoryCode},
+		{"BN_EVENT_CODE", ev.EventCode},
+		{"BN_SYSTEM", ev.System},
+		{"BN_NETWORK", ev.Network},
+		{"BN_HASH", tx.Hash},
+		{"BN_STATUS", tx.Status},
+		{"BN_FROM", tx.From},
+		{"BN_TO", tx.To},
+		{"BN_VALUE", tx.Value},
+		{"BN_NONCE", strconv.FormatUint(tx.Nonce, 10)},
+		{"BN_TYPE", strconv.FormatUint(tx.Type, 10)},
+		{"BN_GAS_PRICE", tx.GasPrice},
+		{"BN_MAX_FEE_PER_GAS", tx.MaxFeePerGas},
+		{"BN_MAX_PRIORITY_FEE_PER_GAS", tx.MaxPriorityFeePerGas},
+		{"BN_BLOCK_NUMBER", strconv.FormatUint(tx.BlockNumber, 10)},
+		{"BN_TIME_PENDING", tx.TimePending},
+		{"BN_WATCHED_ADDRESS", tx.WatchedAddress},
+		{"BN_DIRECTION", tx.Direction},
+		{"BN_COUNTERPARTY", tx.Counterparty},
+		{"BN_ASSET", tx.Asset},
+	}
+	env := make([]string, len(vars))
+	for i, v := range vars {
+		env[i] = v.name + "=" + v.value
+	}
+	return env
+}
@@ -0,0 +1,141 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// syncBuffer is a bytes.Buffer safe for use by concurrent commands
+type syncBuffer struct {
+	mx  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buf.String()
+}
+
+func testEvent(hash, status, value string) *client.EthTxPayload {
+	msg := &client.EthTxPayload{}
+	msg.Event.EventCode = "txPool"
+	msg.Event.Transaction.Hash = hash
+	msg.Event.Transaction.Status = status
+	msg.Event.Transaction.From = "0xaa"
+	msg.Event.Transaction.Value = value
+	return msg
+}
+
+func TestExec(t *testing.T) {
+	var out syncBuffer
+	e, err := NewExec(ExecOpts{
+		// the hash is read from the json on stdin and compared with the environment
+		Command: `grep -q "\"hash\":\"$BN_HASH\"" && echo "$BN_STATUS $BN_FROM $BN_HASH"`,
+		Stdout:  &out,
+	})
+	require.NoError(t, err)
+	require.NoError(t, e.Write(context.Background(), testEvent("0x01", "pending", "0")))
+	require.NoError(t, e.Write(context.Background(), testEvent("0x02", "confirmed", "0")))
+	require.NoError(t, e.Close())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.ElementsMatch(t, []string{"pending 0xaa 0x01", "confirmed 0xaa 0x02"}, lines)
+}
+
+func TestExecErrors(t *testing.T) {
+	var (
+		mx     sync.Mutex
+		failed []error
+	)
+	e, err := NewExec(ExecOpts{
+		Command: `if [ "$BN_HASH" = "0x01" ]; then exit 3; fi; sleep 5`,
+		Timeout: 100 * time.Millisecond,
+		OnError: func(msg *client.EthTxPayload, err error) {
+			mx.Lock()
+			defer mx.Unlock()
+			failed = append(failed, err)
+		},
+	})
+	require.NoError(t, err)
+	start := time.Now()
+	require.NoError(t, e.Write(context.Background(), testEvent("0x01", "pending", "0")))
+	require.NoError(t, e.Write(context.Background(), testEvent("0x02", "pending", "0")))
+	require.NoError(t, e.Close())
+	require.Less(t, time.Since(start), 3*time.Second)
+	require.Len(t, failed, 2)
+
+	_, err = NewExec(ExecOpts{})
+	require.Error(t, err)
+}
+
+func TestFilter(t *testing.T) {
+	f, err := NewFilter(`{{and (eq .Event.Transaction.Status "confirmed") (gt (ether .Event.Transaction.Value) 1.0)}}`)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		msg   *client.EthTxPayload
+		match bool
+	}{
+		{testEvent("0x01", "confirmed", "2000000000000000000"), true},
+		{testEvent("0x02", "confirmed", "1000000000000000000"), false},
+		{testEvent("0x03", "pending", "2000000000000000000"), false},
+	} {
+		ok, err := f.Match(tc.msg)
+		require.NoError(t, err)
+		require.Equal(t, tc.match, ok, tc.msg.Event.Transaction.Hash)
+	}
+
+	_, err = NewFilter("{{")
+	require.Error(t, err)
+	f, err = NewFilter("{{.Nope}}")
+	require.NoError(t, err)
+	_, err = f.Match(testEvent("0x01", "pending", "0"))
+	require.Error(t, err)
+}
+
+// recorder is a sink keeping the hashes of the events written to it
+type recorder struct {
+	hashes []string
+}
+
+func (r *recorder) Write(_ context.Context, msg *client.EthTxPayload) error {
+	r.hashes = append(r.hashes, msg.Event.Transaction.Hash)
+	return nil
+}
+
+func (r *recorder) Close() error {
+	return nil
+}
+
+func TestFiltered(t *testing.T) {
+	f, err := NewFilter(`{{eq .Event.Transaction.ContractCall.MethodName "transfer"}}`)
+	require.NoError(t, err)
+	rec := &recorder{}
+	s := Filtered(rec, f)
+	ctx := context.Background()
+	call := func(msg *client.EthTxPayload, method string) *client.EthTxPayload {
+		msg.Event.Transaction.ContractCall = &client.ContractCall{MethodName: method}
+		return msg
+	}
+	require.NoError(t, s.Write(ctx, call(testEvent("0x01", "pending", "0"), "transfer")))
+	require.NoError(t, s.Write(ctx, call(testEvent("0x02", "pending", "0"), "approve")))
+	// events the filter fails to evaluate on, here for lack of a contract
+	// call, are skipped without failing the sink
+	require.NoError(t, s.Write(ctx, testEvent("0x03", "pending", "0")))
+	require.NoError(t, s.Write(ctx, call(testEvent("0x04", "pending", "0"), "transfer")))
+	require.Equal(t, []string{"0x01", "0x04"}, rec.hashes)
+}
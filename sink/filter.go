@@ -0,0 +1,79 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"math/big"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// filterFuncs are available to filter expressions in addition to the text/template builtins
+var filterFuncs = template.FuncMap{
+	"lower":     strings.ToLower,
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	// ether converts a wei amount to ether so it can be compared with lt, gt etc
+	"ether": func(wei string) float64 {
+		v, ok := new(big.Float).SetString(wei)
+		if !ok {
+			return 0
+		}
+		f, _ := v.Quo(v, big.NewFloat(params.Ether)).Float64()
+		return f
+	},
+}
+
+// Filter matches events against a go text/template expression, an event
+// matches when the template executed against it outputs "true", e.g.
+//
+//	{{and (eq .Event.Transaction.Status "confirmed") (gt (ether .Event.Transaction.Value) 1.0)}}
+type Filter struct {
+	tmpl *template.Template
+}
+
+// NewFilter parses expr into a filter
+func NewFilter(expr string) (*Filter, error) {
+	tmpl, err := template.New("filter").Funcs(filterFuncs).Option("missingkey=error").Parse(expr)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing filter")
+	}
+	return &Filter{tmpl: tmpl}, nil
+}
+
+// Match reports whether msg matches the filter
+func (f *Filter) Match(msg *client.EthTxPayload) (bool, error) {
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, msg); err != nil {
+		return false, errors.Wrap(err, "evaluating filter")
+	}
+	return strings.TrimSpace(buf.String()) == "true", nil
+}
+
+// Filtered returns a sink writing the events matching f to s. Events the
+// filter fails to evaluate on are logged and skipped.
+func Filtered(s Sink, f *Filter) Sink {
+	return filtered{Sink: s, filter: f}
+}
+
+type filtered struct {
+	Sink
+	filter *Filter
+}
+
+func (s filtered) Write(ctx context.Context, msg *client.EthTxPayload) error {
+	ok, err := s.filter.Match(msg)
+	if err != nil {
+		log.Printf("skipping event hash:%v reason:%v", msg.Event.Transaction.Hash, err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return s.Sink.Write(ctx, msg)
+}
@@ -0,0 +1,16 @@
+// Package sink provides destinations that events received from the
+// blocknative api can be forwarded to
+package sink
+
+import (
+	"context"
+
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// Sink consumes events received from the api. Write has the same
+// signature as client.Handler so a sink can be passed to Client.Listen.
+type Sink interface {
+	Write(ctx context.Context, msg *client.EthTxPayload) error
+	Close() error
+}
@@ -0,0 +1,228 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+const (
+	// DefaultSignatureHeader is the header carrying the HMAC signature of a webhook body
+	DefaultSignatureHeader = "X-Blocknative-Signature"
+	// DefaultWebhookBackoff is the delay before the first retry of a delivery
+	DefaultWebhookBackoff = 500 * time.Millisecond
+	// DefaultWebhookTimeout is the timeout of a single delivery attempt
+	DefaultWebhookTimeout = 10 * time.Second
+)
+
+// WebhookOpts provides configuration over webhook delivery
+type WebhookOpts struct {
+	// URLs every event is posted to
+	URLs []string
+	// Secret used to sign request bodies with HMAC-SHA256, signing is disabled if empty
+	Secret string
+	// SignatureHeader overrides DefaultSignatureHeader
+	SignatureHeader string
+	// MaxRetries is the number of times a failed delivery is retried
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubling on every attempt up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Concurrency limits the number of events being delivered at once
+	Concurrency int
+	// Timeout of a single delivery attempt
+	Timeout time.Duration
+	// DeadLetterPath is a file that deliveries which exhausted their retries are appended to
+	DeadLetterPath string
+	HTTPClient     *http.Client
+}
+
+// DeadLetter is the record written for a delivery which could not be completed
+type DeadLetter struct {
+	URL       string          `json:"url"`
+	Error     string          `json:"error"`
+	Attempts  int             `json:"attempts"`
+	Timestamp time.Time       `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Webhook is a Sink that posts events as JSON to a set of urls
+type Webhook struct {
+	opts       WebhookOpts
+	sem        chan struct{}
+	wg         sync.WaitGroup
+	deadLetter *os.File
+	mx         sync.Mutex
+	// closed is closed by Close to abandon pending retries
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewWebhook returns a new webhook sink
+func NewWebhook(opts WebhookOpts) (*Webhook, error) {
+	if len(opts.URLs) == 0 {
+		return nil, errors.New("no webhook urls provided")
+	}
+	if opts.SignatureHeader == "" {
+		opts.SignatureHeader = DefaultSignatureHeader
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultWebhookBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultWebhookTimeout
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	w := &Webhook{opts: opts, sem: make(chan struct{}, opts.Concurrency), closed: make(chan struct{})}
+	if opts.DeadLetterPath != "" {
+		f, err := os.OpenFile(opts.DeadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening dead letter file")
+		}
+		w.deadLetter = f
+	}
+	return w, nil
+}
+
+// Write queues msg for delivery to every url. It blocks while the
+// concurrency limit is reached and returns once delivery has started.
+func (w *Webhook) Write(ctx context.Context, msg *client.EthTxPayload) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "marshal event")
+	}
+	select {
+	case w.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	w.wg.Add(1)
+	go func() {
+		defer func() {
+			<-w.sem
+			w.wg.Done()
+		}()
+		// deliveries outlive ctx so that Close can flush in-flight attempts
+		for _, url := range w.opts.URLs {
+			w.deliver(context.Background(), url, body)
+		}
+	}()
+	return nil
+}
+
+// deliver posts body to url, retrying with backoff and writing a dead
+// letter record if all attempts fail or the webhook is closed before they do
+func (w *Webhook) deliver(ctx context.Context, url string, body []byte) {
+	backoff := w.opts.Backoff
+	var err error
+	attempts := 0
+retries:
+	for attempts <= w.opts.MaxRetries {
+		if attempts > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-w.closed:
+				err = errors.Wrap(err, "webhook closed before retrying")
+				break retries
+			}
+			if backoff *= 2; backoff > w.opts.MaxBackoff {
+				backoff = w.opts.MaxBackoff
+			}
+		}
+		attempts++
+		var retry bool
+		if retry, err = w.post(ctx, url, body); err == nil || !retry {
+			break
+		}
+	}
+	if err != nil {
+		w.writeDeadLetter(DeadLetter{
+			URL:       url,
+			Error:     err.Error(),
+			Attempts:  attempts,
+			Timestamp: time.Now(),
+			Payload:   body,
+		})
+	}
+}
+
+// post performs a single delivery attempt, reporting whether a failure may be retried
+func (w *Webhook) post(ctx context.Context, url string, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, w.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.opts.Secret != "" {
+		req.Header.Set(w.opts.SignatureHeader, Sign([]byte(w.opts.Secret), body))
+	}
+	resp, err := w.opts.HTTPClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = errors.Errorf("unexpected response status:%v", resp.Status)
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+func (w *Webhook) writeDeadLetter(record DeadLetter) {
+	if w.deadLetter == nil {
+		return
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	_, _ = w.deadLetter.Write(append(line, '\n'))
+}
+
+// Close waits for in-flight delivery attempts to complete, abandoning
+// pending retries to the dead letter file, and closes the dead letter file.
+// Closing more than once returns the result of the first call.
+func (w *Webhook) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+		w.wg.Wait()
+		if w.deadLetter != nil {
+			w.closeErr = w.deadLetter.Close()
+		}
+	})
+	return w.closeErr
+}
+
+// Sign returns the signature header value for body, which receivers can
+// recompute with the shared secret to verify a delivery
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
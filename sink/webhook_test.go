@@ -0,0 +1,126 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+func TestWebhook(t *testing.T) {
+	var (
+		calls     int32
+		delivered = make(chan client.EthTxPayload, 1)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, Sign([]byte("secret"), body), r.Header.Get(DefaultSignatureHeader))
+		// fail the first attempt to exercise retries
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var msg client.EthTxPayload
+		require.NoError(t, json.Unmarshal(body, &msg))
+		delivered <- msg
+	}))
+	defer srv.Close()
+
+	hook, err := NewWebhook(WebhookOpts{
+		URLs:       []string{srv.URL},
+		Secret:     "secret",
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+	})
+	require.NoError(t, err)
+	msg := &client.EthTxPayload{}
+	msg.Event.Transaction.Hash = "0x01"
+	require.NoError(t, hook.Write(context.Background(), msg))
+	require.Equal(t, "0x01", (<-delivered).Event.Transaction.Hash)
+	require.NoError(t, hook.Close())
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestWebhookCloseAbandonsRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "dead.ndjson")
+	hook, err := NewWebhook(WebhookOpts{
+		URLs:           []string{srv.URL},
+		MaxRetries:     5,
+		Backoff:        time.Hour,
+		DeadLetterPath: path,
+	})
+	require.NoError(t, err)
+	require.NoError(t, hook.Write(context.Background(), &client.EthTxPayload{}))
+	start := time.Now()
+	require.NoError(t, hook.Close())
+	require.Less(t, time.Since(start), time.Minute)
+	// closing again, e.g. from a deferred close, is harmless
+	require.NoError(t, hook.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var record DeadLetter
+	require.NoError(t, json.Unmarshal(data, &record))
+	require.Equal(t, 1, record.Attempts)
+	require.Contains(t, record.Error, "webhook closed before retrying")
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "dead.ndjson")
+	hook, err := NewWebhook(WebhookOpts{
+		URLs:           []string{srv.URL},
+		MaxRetries:     3,
+		Backoff:        time.Millisecond,
+		Concurrency:    1,
+		DeadLetterPath: path,
+	})
+	require.NoError(t, err)
+	for _, hash := range []string{"0x01", "0x02"} {
+		msg := &client.EthTxPayload{}
+		msg.Event.Transaction.Hash = hash
+		require.NoError(t, hook.Write(context.Background(), msg))
+	}
+	require.NoError(t, hook.Close())
+	// client errors are not retried
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var records []DeadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record DeadLetter
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	require.Equal(t, srv.URL, records[0].URL)
+	require.Equal(t, 1, records[0].Attempts)
+	var payload client.EthTxPayload
+	require.NoError(t, json.Unmarshal(records[1].Payload, &payload))
+	require.Equal(t, "0x02", payload.Event.Transaction.Hash)
+}
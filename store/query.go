@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// TxRecord is a stored transaction event as returned by queries
+type TxRecord struct {
+	EventID        int64     `json:"eventId"`
+	ReceivedAt     time.Time `json:"receivedAt"`
+	EventCode      string    `json:"eventCode"`
+	Network        string    `json:"network"`
+	Hash           string    `json:"hash"`
+	Status         string    `json:"status"`
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+	Value          string    `json:"value"`
+	WatchedAddress string    `json:"watchedAddress"`
+	BlockNumber    uint64    `json:"blockNumber"`
+}
+
+const selectTxRecords = `SELECT e.id, e.received_at, e.event_code, e.network,
+	t.hash, t.status, t.from_address, t.to_address, t.value, t.watched_address, t.block_number
+	FROM events e JOIN transactions t ON t.event_id = e.id `
+
+// TxEvents returns every event stored for the transaction hash in the order they were received
+func (s *SQLite) TxEvents(ctx context.Context, hash string) ([]TxRecord, error) {
+	return s.queryTxRecords(ctx, selectTxRecords+`WHERE t.hash = ? ORDER BY e.id`, lower(hash))
+}
+
+// AddressEvents returns the latest events sent from, to or watched for address, newest first
+func (s *SQLite) AddressEvents(ctx context.Context, address string, limit int) ([]TxRecord, error) {
+	address = lower(address)
+	return s.queryTxRecords(ctx, selectTxRecords+`WHERE t.from_address = ? OR t.to_address = ? OR t.watched_address = ?
+		ORDER BY e.id DESC LIMIT ?`, address, address, address, limit)
+}
+
+// BlockEvents returns the events for transactions included in the block with the given number
+func (s *SQLite) BlockEvents(ctx context.Context, number uint64) ([]TxRecord, error) {
+	return s.queryTxRecords(ctx, selectTxRecords+`WHERE t.block_number = ? ORDER BY e.id`, number)
+}
+
+func (s *SQLite) queryTxRecords(ctx context.Context, query string, args ...interface{}) ([]TxRecord, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []TxRecord
+	for rows.Next() {
+		var r TxRecord
+		if err := rows.Scan(
+			&r.EventID, &r.ReceivedAt, &r.EventCode, &r.Network,
+			&r.Hash, &r.Status, &r.From, &r.To, &r.Value, &r.WatchedAddress, &r.BlockNumber,
+		); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,208 @@
+// Package store archives events received from the blocknative api
+// into an embedded sqlite database
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+	_ "modernc.org/sqlite" // registers the sqlite driver
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS events (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	received_at    TIMESTAMP NOT NULL,
+	timestamp      TIMESTAMP,
+	version        INTEGER,
+	server_version TEXT,
+	connection_id  TEXT,
+	status         TEXT,
+	category_code  TEXT,
+	event_code     TEXT,
+	system         TEXT,
+	network        TEXT
+);
+CREATE TABLE IF NOT EXISTS transactions (
+	event_id                 INTEGER PRIMARY KEY REFERENCES events(id),
+	hash                     TEXT NOT NULL,
+	status                   TEXT,
+	type                     INTEGER,
+	from_address             TEXT,
+	to_address               TEXT,
+	value                    TEXT,
+	gas                      REAL,
+	gas_price                TEXT,
+	max_fee_per_gas          TEXT,
+	max_priority_fee_per_gas TEXT,
+	base_fee_per_gas         TEXT,
+	gas_used                 REAL,
+	nonce                    INTEGER,
+	block_hash               TEXT,
+	block_number             INTEGER,
+	transaction_index        INTEGER,
+	input                    TEXT,
+	asset                    TEXT,
+	watched_address          TEXT,
+	direction                TEXT,
+	counterparty             TEXT,
+	monitor_id               TEXT,
+	monitor_version          TEXT,
+	time_pending             TEXT,
+	blocks_pending           INTEGER,
+	timestamp                TIMESTAMP,
+	pending_timestamp        TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS transactions_hash ON transactions(hash);
+CREATE INDEX IF NOT EXISTS transactions_from ON transactions(from_address);
+CREATE INDEX IF NOT EXISTS transactions_to ON transactions(to_address);
+CREATE INDEX IF NOT EXISTS transactions_watched_address ON transactions(watched_address);
+CREATE INDEX IF NOT EXISTS transactions_block_number ON transactions(block_number);
+CREATE TABLE IF NOT EXISTS internal_transactions (
+	event_id         INTEGER NOT NULL REFERENCES events(id),
+	idx              INTEGER NOT NULL,
+	type             TEXT,
+	from_address     TEXT,
+	to_address       TEXT,
+	input            TEXT,
+	gas              INTEGER,
+	gas_used         INTEGER,
+	value            TEXT,
+	contract_address TEXT,
+	method_name      TEXT,
+	PRIMARY KEY (event_id, idx)
+);
+CREATE INDEX IF NOT EXISTS internal_transactions_from ON internal_transactions(from_address);
+CREATE INDEX IF NOT EXISTS internal_transactions_to ON internal_transactions(to_address);
+CREATE TABLE IF NOT EXISTS net_balance_changes (
+	id                     INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id               INTEGER NOT NULL REFERENCES events(id),
+	address                TEXT,
+	delta                  TEXT,
+	asset_type             TEXT,
+	asset_symbol           TEXT,
+	asset_contract_address TEXT
+);
+CREATE INDEX IF NOT EXISTS net_balance_changes_event ON net_balance_changes(event_id);
+CREATE INDEX IF NOT EXISTS net_balance_changes_address ON net_balance_changes(address);
+CREATE TABLE IF NOT EXISTS balance_change_breakdowns (
+	balance_change_id INTEGER NOT NULL REFERENCES net_balance_changes(id),
+	counterparty      TEXT,
+	amount            TEXT
+);
+CREATE INDEX IF NOT EXISTS balance_change_breakdowns_change ON balance_change_breakdowns(balance_change_id);
+`
+
+// SQLite stores events in normalized tables of a sqlite database, it implements sink.Sink
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, creating the schema if needed
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening database")
+	}
+	// sqlite only supports a single writer
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "creating schema")
+	}
+	return &SQLite{db: db}, nil
+}
+
+// DB returns the underlying database for custom queries
+func (s *SQLite) DB() *sql.DB {
+	return s.db
+}
+
+// Write stores msg along with its internal transactions and net balance changes
+func (s *SQLite) Write(ctx context.Context, msg *client.EthTxPayload) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	ev := msg.Event
+	res, err := tx.ExecContext(ctx, `INSERT INTO events
+		(received_at, timestamp, version, server_version, connection_id, status, category_code, event_code, system, network)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		time.Now().UTC(), msg.TimeStamp, msg.Version, msg.ServerVersion, msg.ConnectionID, msg.Status,
+		ev.CategoryCode, ev.EventCode, ev.System, ev.Network,
+	)
+	if err != nil {
+		return errors.Wrap(err, "inserting event")
+	}
+	eventID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	t := ev.Transaction
+	if _, err = tx.ExecContext(ctx, `INSERT INTO transactions
+		(event_id, hash, status, type, from_address, to_address, value, gas, gas_price, max_fee_per_gas,
+		max_priority_fee_per_gas, base_fee_per_gas, gas_used, nonce, block_hash, block_number, transaction_index,
+		input, asset, watched_address, direction, counterparty, monitor_id, monitor_version, time_pending,
+		blocks_pending, timestamp, pending_timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		eventID, lower(t.Hash), t.Status, t.Type, lower(t.From), lower(t.To), t.Value, t.Gas, t.GasPrice, t.MaxFeePerGas,
+		t.MaxPriorityFeePerGas, t.BaseFeePerGas, t.GasUsed, t.Nonce, t.BlockHash, t.BlockNumber, t.TransactionIndex,
+		t.Input, t.Asset, lower(t.WatchedAddress), t.Direction, lower(t.Counterparty), t.MonitorID, t.MonitorVersion, t.TimePending,
+		t.BlocksPending, t.TimeStamp, t.PendingTimeStamp,
+	); err != nil {
+		return errors.Wrap(err, "inserting transaction")
+	}
+	for i, itx := range t.InternalTransactions {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO internal_transactions
+			(event_id, idx, type, from_address, to_address, input, gas, gas_used, value, contract_address, method_name)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			eventID, i, itx.Type, lower(itx.From), lower(itx.To), itx.Input, itx.Gas, itx.GasUsed, itx.Value,
+			lower(itx.ContractCall.ContractAddress), itx.ContractCall.MethodName,
+		); err != nil {
+			return errors.Wrap(err, "inserting internal transaction")
+		}
+	}
+	for _, nbc := range t.NetBalanceChanges {
+		for _, change := range nbc.BalanceChanges {
+			res, err = tx.ExecContext(ctx, `INSERT INTO net_balance_changes
+				(event_id, address, delta, asset_type, asset_symbol, asset_contract_address)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				eventID, lower(nbc.Address), client.AmountString(change.Delta), change.Asset.Type, change.Asset.Symbol, lower(change.Asset.ContractAddress),
+			)
+			if err != nil {
+				return errors.Wrap(err, "inserting net balance change")
+			}
+			changeID, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			for _, b := range change.Breakdown {
+				if _, err = tx.ExecContext(ctx, `INSERT INTO balance_change_breakdowns
+					(balance_change_id, counterparty, amount) VALUES (?, ?, ?)`,
+					changeID, lower(b.Counterparty), client.AmountString(b.Amount),
+				); err != nil {
+					return errors.Wrap(err, "inserting balance change breakdown")
+				}
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the database
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func lower(s string) string {
+	return strings.ToLower(s)
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+const confirmedEvent = `{
+	"version": 0,
+	"serverVersion": "0.138.0",
+	"timeStamp": "2023-06-01T10:00:12.000Z",
+	"connectionId": "c1",
+	"status": "ok",
+	"event": {
+		"categoryCode": "activeAddress",
+		"eventCode": "txConfirmed",
+		"dappId": "key",
+		"blockchain": {"system": "ethereum", "network": "main"},
+		"transaction": {
+			"status": "confirmed",
+			"hash": "0xABC",
+			"from": "0xAA",
+			"to": "0xBB",
+			"value": "1000",
+			"blockNumber": 17000000,
+			"watchedAddress": "0xaa",
+			"internalTransactions": [
+				{"type": "CALL", "from": "0xbb", "to": "0xcc", "gas": 21000, "value": "10",
+				 "contractCall": {"contractAddress": "0xCC", "methodName": "transfer"}}
+			],
+			"netBalanceChanges": [
+				{"address": "0xaa", "balanceChanges": [
+					{"delta": "-1000", "asset": {"type": "ether", "symbol": "ETH"},
+					 "breakdown": [{"counterparty": "0xBB", "amount": "1000"}]}
+				]}
+			]
+		}
+	}
+}`
+
+func TestSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "events.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	pending := &client.EthTxPayload{}
+	pending.Event.EventCode = "txPool"
+	pending.Event.Network = "main"
+	pending.Event.Transaction.Hash = "0xabc"
+	pending.Event.Transaction.Status = "pending"
+	pending.Event.Transaction.From = "0xaa"
+	require.NoError(t, db.Write(ctx, pending))
+
+	var confirmed client.EthTxPayload
+	require.NoError(t, json.Unmarshal([]byte(confirmedEvent), &confirmed))
+	require.NoError(t, db.Write(ctx, &confirmed))
+
+	records, err := db.TxEvents(ctx, "0xAbC")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "pending", records[0].Status)
+	require.Equal(t, "confirmed", records[1].Status)
+	require.Equal(t, "0xbb", records[1].To)
+	require.Equal(t, "main", records[1].Network)
+
+	records, err = db.AddressEvents(ctx, "0xBB", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "txConfirmed", records[0].EventCode)
+
+	records, err = db.BlockEvents(ctx, 17000000)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	var method, amount string
+	require.NoError(t, db.DB().QueryRow(`SELECT method_name FROM internal_transactions WHERE to_address = '0xcc'`).Scan(&method))
+	require.Equal(t, "transfer", method)
+	require.NoError(t, db.DB().QueryRow(`SELECT b.amount FROM net_balance_changes n
+		JOIN balance_change_breakdowns b ON b.balance_change_id = n.id
+		WHERE n.address = '0xaa' AND b.counterparty = '0xbb'`).Scan(&amount))
+	require.Equal(t, "1000", amount)
+}
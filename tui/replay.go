@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// ReadEvents reads newline delimited json events, such as those written by the ndjson output format
+func ReadEvents(r io.Reader) ([]*client.EthTxPayload, error) {
+	var events []*client.EthTxPayload
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var msg client.EthTxPayload
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, errors.Wrapf(err, "decoding event line:%v", line)
+		}
+		events = append(events, &msg)
+	}
+	return events, scanner.Err()
+}
+
+// Replay sends events on the returned channel interval apart, closing it
+// once all events are sent or ctx is done
+func Replay(ctx context.Context, events []*client.EthTxPayload, interval time.Duration) <-chan *client.EthTxPayload {
+	ch := make(chan *client.EthTxPayload)
+	go func() {
+		defer close(ch)
+		for i, msg := range events {
+			if i > 0 && interval > 0 {
+				select {
+				case <-time.After(interval):
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case ch <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
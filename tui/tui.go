@@ -0,0 +1,373 @@
+// Package tui implements a terminal dashboard for monitoring events
+// received from the blocknative api as they arrive
+package tui
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// DefaultMaxRows is the number of transactions kept by a model when none is given
+const DefaultMaxRows = 500
+
+// EventMsg delivers an event to the model
+type EventMsg struct {
+	*client.EthTxPayload
+}
+
+// EndMsg is sent once the event stream is closed
+type EndMsg struct{}
+
+// Model is the bubbletea model of the dashboard. It shows the latest
+// event of every transaction, newest first.
+type Model struct {
+	events  <-chan *client.EthTxPayload
+	maxRows int
+
+	rows     []*client.EthTxPayload
+	received int
+	ended    bool
+
+	paused  bool
+	pending []*client.EthTxPayload
+
+	filter  string
+	editing bool
+
+	cursor int
+	detail bool
+
+	width, height int
+
+	now func() time.Time
+}
+
+// New returns a model reading events from events, keeping at most maxRows transactions
+func New(events <-chan *client.EthTxPayload, maxRows int) *Model {
+	if maxRows <= 0 {
+		maxRows = DefaultMaxRows
+	}
+	return &Model{events: events, maxRows: maxRows, now: time.Now}
+}
+
+// Init starts waiting for events
+func (m *Model) Init() tea.Cmd {
+	return m.wait
+}
+
+// wait blocks until the next event is received
+func (m *Model) wait() tea.Msg {
+	msg, ok := <-m.events
+	if !ok {
+		return EndMsg{}
+	}
+	return EventMsg{msg}
+}
+
+// Update handles events, key presses and terminal resizes
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case EventMsg:
+		m.received++
+		if m.paused {
+			m.pending = append(m.pending, msg.EthTxPayload)
+			// drop the oldest events rather than buffering a long pause
+			if len(m.pending) > m.maxRows {
+				m.pending = m.pending[len(m.pending)-m.maxRows:]
+			}
+		} else {
+			m.add(msg.EthTxPayload)
+		}
+		return m, m.wait
+	case EndMsg:
+		m.ended = true
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		return m, m.key(msg)
+	}
+	return m, nil
+}
+
+// add stores the event, replacing the previous event of the same transaction
+func (m *Model) add(msg *client.EthTxPayload) {
+	hash := strings.ToLower(msg.Event.Transaction.Hash)
+	for i, row := range m.rows {
+		if strings.ToLower(row.Event.Transaction.Hash) == hash {
+			m.rows = append(m.rows[:i], m.rows[i+1:]...)
+			break
+		}
+	}
+	m.rows = append([]*client.EthTxPayload{msg}, m.rows...)
+	if len(m.rows) > m.maxRows {
+		m.rows = m.rows[:m.maxRows]
+	}
+}
+
+func (m *Model) key(msg tea.KeyMsg) tea.Cmd {
+	if msg.Type == tea.KeyCtrlC {
+		return tea.Quit
+	}
+	if m.editing {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.editing = false
+		case tea.KeyEsc:
+			m.editing, m.filter = false, ""
+		case tea.KeyBackspace:
+			if len(m.filter) > 0 {
+				m.filter = m.filter[:len(m.filter)-1]
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.filter += string(msg.Runes)
+		}
+		m.cursor = 0
+		return nil
+	}
+	if m.detail {
+		switch msg.String() {
+		case "esc", "enter", "backspace":
+			m.detail = false
+		case "q":
+			return tea.Quit
+		}
+		return nil
+	}
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "/":
+		m.editing = true
+	case "esc":
+		m.filter = ""
+	case "p", " ":
+		m.togglePause()
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.visible())-1 {
+			m.cursor++
+		}
+	case "enter":
+		if m.selected() != nil {
+			m.detail = true
+		}
+	}
+	return nil
+}
+
+func (m *Model) togglePause() {
+	m.paused = !m.paused
+	if !m.paused {
+		for _, msg := range m.pending {
+			m.add(msg)
+		}
+		m.pending = nil
+	}
+}
+
+// visible returns the rows matching the filter
+func (m *Model) visible() []*client.EthTxPayload {
+	if m.filter == "" {
+		return m.rows
+	}
+	filter := strings.ToLower(m.filter)
+	var out []*client.EthTxPayload
+	for _, row := range m.rows {
+		tx := row.Event.Transaction
+		for _, field := range []string{tx.Hash, tx.Status, tx.From, tx.To, tx.WatchedAddress, row.Event.EventCode, methodName(row)} {
+			if strings.Contains(strings.ToLower(field), filter) {
+				out = append(out, row)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (m *Model) selected() *client.EthTxPayload {
+	rows := m.visible()
+	if m.cursor < len(rows) {
+		return rows[m.cursor]
+	}
+	return nil
+}
+
+// View renders the table, or the details of the selected transaction
+func (m *Model) View() string {
+	if m.detail {
+		if msg := m.selected(); msg != nil {
+			return detailView(msg, m.now())
+		}
+	}
+	var b strings.Builder
+	b.WriteString(m.statusLine())
+	b.WriteByte('\n')
+	fmt.Fprintf(&b, rowFormat, " ", "HASH", "STATUS", "FROM", "TO", "VALUE (ETH)", "TIP", "FEE", "PENDING", "METHOD")
+	rows := m.visible()
+	now := m.now()
+	limit := len(rows)
+	// leave room for the status, header and help lines
+	if m.height > 3 && limit > m.height-3 {
+		limit = m.height - 3
+	}
+	start := 0
+	if m.cursor >= limit {
+		start = m.cursor - limit + 1
+	}
+	for i := start; i < start+limit && i < len(rows); i++ {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		b.WriteString(row(cursor, rows[i], now))
+	}
+	b.WriteString(m.help())
+	return b.String()
+}
+
+const rowFormat = "%s %-13s %-10s %-13s %-13s %12s %8s %8s %9s %s\n"
+
+func row(cursor string, msg *client.EthTxPayload, now time.Time) string {
+	tx := msg.Event.Transaction
+	fee, tip := gasGwei(msg)
+	return fmt.Sprintf(rowFormat, cursor, short(tx.Hash), tx.Status, short(tx.From), short(tx.To),
+		formatEther(tx.Value), tip, fee, timePending(tx, now), methodName(msg))
+}
+
+func (m *Model) statusLine() string {
+	parts := []string{fmt.Sprintf("events: %d", m.received), fmt.Sprintf("transactions: %d", len(m.rows))}
+	if m.filter != "" || m.editing {
+		parts = append(parts, fmt.Sprintf("filter: %s (%d)", m.filter, len(m.visible())))
+	}
+	if m.paused {
+		parts = append(parts, fmt.Sprintf("PAUSED (%d new)", len(m.pending)))
+	}
+	if m.ended {
+		parts = append(parts, "stream ended")
+	}
+	return strings.Join(parts, "  ")
+}
+
+func (m *Model) help() string {
+	if m.editing {
+		return "filter: " + m.filter + "_  enter apply  esc clear\n"
+	}
+	return "/ filter  p pause  ↑/↓ select  enter details  q quit\n"
+}
+
+func detailView(msg *client.EthTxPayload, now time.Time) string {
+	tx := msg.Event.Transaction
+	fee, tip := gasGwei(msg)
+	var b strings.Builder
+	fmt.Fprintf(&b, "hash:         %s\n", tx.Hash)
+	fmt.Fprintf(&b, "event:        %s\n", msg.Event.EventCode)
+	fmt.Fprintf(&b, "status:       %s\n", tx.Status)
+	fmt.Fprintf(&b, "from:         %s\n", tx.From)
+	fmt.Fprintf(&b, "to:           %s\n", tx.To)
+	fmt.Fprintf(&b, "value:        %s ETH\n", formatEther(tx.Value))
+	fmt.Fprintf(&b, "type:         %d\n", tx.Type)
+	fmt.Fprintf(&b, "fee/tip:      %s/%s gwei\n", fee, tip)
+	fmt.Fprintf(&b, "pending:      %s\n", timePending(tx, now))
+	fmt.Fprintf(&b, "method:       %s\n", methodName(msg))
+	if tx.BlockNumber != 0 {
+		fmt.Fprintf(&b, "block:        %d\n", tx.BlockNumber)
+	}
+	fmt.Fprintf(&b, "\ninternal transactions (%d)\n", len(tx.InternalTransactions))
+	for _, itx := range tx.InternalTransactions {
+		fmt.Fprintf(&b, "  %-12s %s -> %s  %s ETH  %s\n", itx.Type, itx.From, itx.To, formatEther(itx.Value), itx.ContractCall.MethodName)
+	}
+	fmt.Fprintf(&b, "\nnet balance changes (%d)\n", len(tx.NetBalanceChanges))
+	for _, change := range tx.NetBalanceChanges {
+		fmt.Fprintf(&b, "  %s\n", change.Address)
+		for _, bc := range change.BalanceChanges {
+			fmt.Fprintf(&b, "    %s %s\n", formatDelta(tx, bc), bc.Asset.Symbol)
+		}
+	}
+	b.WriteString("\nesc back  q quit\n")
+	return b.String()
+}
+
+// methodName returns the name of the method called by the transaction, or
+// its selector when the api did not decode it
+func methodName(msg *client.EthTxPayload) string {
+	if call := msg.Event.Transaction.ContractCall; call != nil && call.MethodName != "" {
+		return call.MethodName
+	}
+	input := msg.Event.Transaction.Input
+	if len(input) < 10 {
+		return ""
+	}
+	return input[:10]
+}
+
+// gasGwei returns the max fee and tip of dynamic fee transactions, or the gas price of legacy ones
+func gasGwei(msg *client.EthTxPayload) (fee, tip string) {
+	if maxFee, maxTip, err := client.ParseGas(msg); err == nil {
+		return formatGwei(maxFee), formatGwei(maxTip)
+	}
+	if price, err := strconv.ParseFloat(msg.Event.Transaction.GasPrice, 64); err == nil {
+		return formatGwei(price / params.GWei), "-"
+	}
+	return "-", "-"
+}
+
+func formatGwei(gwei float64) string {
+	return strconv.FormatFloat(gwei, 'f', 2, 64)
+}
+
+// formatEther formats a wei amount in ether
+func formatEther(wei string) string {
+	v, ok := new(big.Float).SetString(wei)
+	if !ok {
+		return wei
+	}
+	return v.Quo(v, big.NewFloat(params.Ether)).Text('f', 4)
+}
+
+// formatDelta formats a balance change in whole units when the decimals of the asset are known
+func formatDelta(tx client.TransactionPayload, bc client.BalanceChange) string {
+	if decimals, ok := tx.AssetDecimals(bc.Asset); ok {
+		return client.ScaleAmount(bc.Delta, decimals).Text('f', 4)
+	}
+	if bc.Delta == nil {
+		return "0"
+	}
+	return bc.Delta.String()
+}
+
+// timePending returns how long tx has been pending. The api reports a time
+// pending of 0 until the transaction leaves the mempool, so pending
+// transactions are measured from the time they were first seen.
+func timePending(tx client.TransactionPayload, now time.Time) string {
+	if tx.Status == "pending" && !tx.PendingTimeStamp.IsZero() {
+		return now.Sub(tx.PendingTimeStamp).Round(100 * time.Millisecond).String()
+	}
+	return formatPending(tx.TimePending)
+}
+
+// formatPending formats the time pending in milliseconds reported by the api
+func formatPending(ms string) string {
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return ms
+	}
+	return (time.Duration(n) * time.Millisecond).Round(100 * time.Millisecond).String()
+}
+
+// short abbreviates hashes and addresses to their first and last characters
+func short(s string) string {
+	if len(s) <= 13 {
+		return s
+	}
+	return s[:6] + ".." + s[len(s)-5:]
+}
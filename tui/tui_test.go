@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+	"github.com/tiennampham23/go-blocknative/client"
+)
+
+// replay feeds the events in testdata to a new model until the stream ends
+func replay(t *testing.T) *Model {
+	f, err := os.Open("testdata/events.ndjson")
+	require.NoError(t, err)
+	defer f.Close()
+	events, err := ReadEvents(f)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	m := New(Replay(context.Background(), events, 0), 0)
+	cmd := m.Init()
+	for cmd != nil {
+		_, cmd = m.Update(cmd())
+	}
+	return m
+}
+
+func press(m *Model, keys ...string) {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		m.Update(msg)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	m := replay(t)
+	view := m.View()
+	require.Contains(t, view, "events: 3  transactions: 2  stream ended")
+	lines := strings.Split(view, "\n")
+	// the confirmed transaction replaces its pending event at the top
+	require.Contains(t, lines[2], "> 0xaaaa..00001 confirmed")
+	require.Contains(t, lines[2], "1.5000")
+	require.Contains(t, lines[2], "2.00    30.00")
+	require.Contains(t, lines[2], "12.5s")
+	// decoded calls show the method name rather than the selector
+	require.Contains(t, lines[2], "transfer")
+	// legacy transactions report their gas price
+	require.Contains(t, lines[3], "0xbbbb..00002 pending")
+	require.Contains(t, lines[3], "-    25.00")
+}
+
+func TestFilter(t *testing.T) {
+	m := replay(t)
+	press(m, "/", "0", "x", "3", "3", "enter")
+	view := m.View()
+	require.Contains(t, view, "filter: 0x33 (1)")
+	require.NotContains(t, view, "0xaaaa")
+	require.Contains(t, view, "0xbbbb")
+
+	press(m, "esc")
+	require.Contains(t, m.View(), "0xaaaa")
+}
+
+func TestPause(t *testing.T) {
+	m := New(nil, 0)
+	press(m, "p")
+	msg := &client.EthTxPayload{}
+	msg.Event.Transaction.Hash = "0x01"
+	m.Update(EventMsg{msg})
+	require.Contains(t, m.View(), "PAUSED (1 new)")
+	require.Empty(t, m.visible())
+
+	press(m, "p")
+	require.NotContains(t, m.View(), "PAUSED")
+	require.Len(t, m.visible(), 1)
+}
+
+func TestDetail(t *testing.T) {
+	m := replay(t)
+	press(m, "enter")
+	view := m.View()
+	require.Contains(t, view, "status:       confirmed")
+	require.Contains(t, view, "internal transactions (1)")
+	require.Contains(t, view, "CALL         0x2222222222222222222222222222222222222222 -> 0x5555555555555555555555555555555555555555  0.0000 ETH  swap")
+	require.Contains(t, view, "net balance changes (1)")
+	require.Contains(t, view, "-1.5000 ETH")
+
+	press(m, "esc", "down", "enter")
+	require.Contains(t, m.View(), "hash:         0xbbbb")
+}
+
+func TestPauseLimit(t *testing.T) {
+	m := New(nil, 2)
+	press(m, "p")
+	for _, hash := range []string{"0x01", "0x02", "0x03"} {
+		msg := &client.EthTxPayload{}
+		msg.Event.Transaction.Hash = hash
+		m.Update(EventMsg{msg})
+	}
+	require.Contains(t, m.View(), "PAUSED (2 new)")
+
+	press(m, "p")
+	rows := m.visible()
+	require.Len(t, rows, 2)
+	require.Equal(t, "0x03", rows[0].Event.Transaction.Hash)
+	require.Equal(t, "0x02", rows[1].Event.Transaction.Hash)
+}
+
+func TestTimePending(t *testing.T) {
+	seen := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := New(nil, 0)
+	m.now = func() time.Time { return seen.Add(4200 * time.Millisecond) }
+	msg := &client.EthTxPayload{}
+	msg.Event.Transaction.Hash = "0x01"
+	msg.Event.Transaction.Status = "pending"
+	msg.Event.Transaction.TimePending = "0"
+	msg.Event.Transaction.PendingTimeStamp = seen
+	m.Update(EventMsg{msg})
+	require.Contains(t, m.View(), "4.2s")
+
+	press(m, "enter")
+	require.Contains(t, m.View(), "pending:      4.2s")
+}
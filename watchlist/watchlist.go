@@ -0,0 +1,149 @@
+// Package watchlist parses lists of addresses to watch along with
+// optional labels identifying them
+package watchlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Entry is an address to watch with an optional label
+type Entry struct {
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+}
+
+// Parse reads entries from r, detecting the format from its content:
+//
+//   - JSON: an array of addresses, an array of {"address", "label"}
+//     objects or an object mapping addresses to labels
+//   - CSV: address,label rows with an optional header
+//   - plain: one address per line
+//
+// Blank lines and lines starting with # are ignored in the CSV and plain formats.
+func Parse(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	var entries []Entry
+	switch {
+	case len(trimmed) == 0:
+		return nil, nil
+	case trimmed[0] == '[' || trimmed[0] == '{':
+		entries, err = parseJSON(trimmed)
+	default:
+		entries, err = parseLines(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !common.IsHexAddress(e.Address) {
+			return nil, errors.Errorf("invalid address:%v", e.Address)
+		}
+	}
+	return entries, nil
+}
+
+func parseJSON(data []byte) ([]Entry, error) {
+	if data[0] == '{' {
+		var labels map[string]string
+		if err := json.Unmarshal(data, &labels); err != nil {
+			return nil, errors.Wrap(err, "decoding json address list")
+		}
+		entries := make([]Entry, 0, len(labels))
+		for address, label := range labels {
+			entries = append(entries, Entry{Address: address, Label: label})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Address < entries[j].Address })
+		return entries, nil
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "decoding json address list")
+	}
+	entries := make([]Entry, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &entries[i].Address); err == nil {
+			continue
+		}
+		if err := json.Unmarshal(item, &entries[i]); err != nil {
+			return nil, errors.Wrap(err, "decoding json address list")
+		}
+	}
+	return entries, nil
+}
+
+// parseLines parses csv rows and plain lists, a plain list being a csv with a single column
+func parseLines(data []byte) ([]Entry, error) {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	reader := csv.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding csv address list")
+	}
+	// skip a header row
+	if len(records) > 0 && strings.EqualFold(records[0][0], "address") {
+		records = records[1:]
+	}
+	entries := make([]Entry, len(records))
+	for i, record := range records {
+		entries[i].Address = strings.TrimSpace(record[0])
+		if len(record) > 1 {
+			entries[i].Label = strings.TrimSpace(record[1])
+		}
+	}
+	return entries, nil
+}
+
+// Merge combines lists of entries, dropping duplicate addresses. A label
+// set for an address takes precedence over an earlier entry without one.
+func Merge(lists ...[]Entry) []Entry {
+	var out []Entry
+	index := make(map[string]int)
+	for _, list := range lists {
+		for _, e := range list {
+			key := strings.ToLower(e.Address)
+			if i, ok := index[key]; ok {
+				if out[i].Label == "" {
+					out[i].Label = e.Label
+				}
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Labels returns the labels of entries keyed by lowercase address
+func Labels(entries []Entry) map[string]string {
+	labels := make(map[string]string)
+	for _, e := range entries {
+		if e.Label != "" {
+			labels[strings.ToLower(e.Address)] = e.Label
+		}
+	}
+	return labels
+}
@@ -0,0 +1,63 @@
+package watchlist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	addrA = "0xfa6de2697D59E88Ed7Fc4dFE5A33daC43565ea41"
+	addrB = "0x88dF592F8eb5D7Bd38bFeF7dEb0fBc02cf3778a0"
+)
+
+func TestParse(t *testing.T) {
+	for name, tc := range map[string]struct {
+		input string
+		want  []Entry
+	}{
+		"plain": {
+			input: "# wallets\n" + addrA + "\n\n" + addrB + "\n",
+			want:  []Entry{{Address: addrA}, {Address: addrB}},
+		},
+		"csv": {
+			input: "address,label\n" + addrA + ", treasury\n" + addrB + "\n",
+			want:  []Entry{{Address: addrA, Label: "treasury"}, {Address: addrB}},
+		},
+		"json strings": {
+			input: `["` + addrA + `", "` + addrB + `"]`,
+			want:  []Entry{{Address: addrA}, {Address: addrB}},
+		},
+		"json objects": {
+			input: `[{"address": "` + addrA + `", "label": "treasury"}, {"address": "` + addrB + `"}]`,
+			want:  []Entry{{Address: addrA, Label: "treasury"}, {Address: addrB}},
+		},
+		"json map": {
+			input: `{"` + addrA + `": "treasury"}`,
+			want:  []Entry{{Address: addrA, Label: "treasury"}},
+		},
+		"empty": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := Parse(strings.NewReader("not-an-address\n"))
+	require.Error(t, err)
+}
+
+func TestMergeAndLabels(t *testing.T) {
+	merged := Merge(
+		[]Entry{{Address: addrA}},
+		[]Entry{{Address: strings.ToLower(addrA), Label: "treasury"}, {Address: addrB, Label: "ops"}},
+	)
+	require.Equal(t, []Entry{{Address: addrA, Label: "treasury"}, {Address: addrB, Label: "ops"}}, merged)
+	require.Equal(t, map[string]string{
+		strings.ToLower(addrA): "treasury",
+		strings.ToLower(addrB): "ops",
+	}, Labels(merged))
+}